@@ -0,0 +1,35 @@
+package pm
+
+import (
+	"context"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+type ctxMessageMarker struct{}
+
+type ctxSubscriptionInfoMarker struct{}
+
+var (
+	ctxMessageKey          = &ctxMessageMarker{}
+	ctxSubscriptionInfoKey = &ctxSubscriptionInfoMarker{}
+)
+
+// ContextWithMessage adds the message and the subscription info to the context.
+// Subscriber calls it for every message, so that it's needed only when handlers are invoked outside of Subscriber such as in tests.
+func ContextWithMessage(ctx context.Context, info *SubscriptionInfo, m *pubsub.Message) context.Context {
+	ctx = context.WithValue(ctx, ctxSubscriptionInfoKey, info)
+	return context.WithValue(ctx, ctxMessageKey, m)
+}
+
+// MessageFromContext returns the message being handled from the context.
+func MessageFromContext(ctx context.Context) (*pubsub.Message, bool) {
+	m, ok := ctx.Value(ctxMessageKey).(*pubsub.Message)
+	return m, ok
+}
+
+// SubscriptionInfoFromContext returns the info of the subscription which the message being handled belongs to from the context.
+func SubscriptionInfoFromContext(ctx context.Context) (*SubscriptionInfo, bool) {
+	info, ok := ctx.Value(ctxSubscriptionInfoKey).(*SubscriptionInfo)
+	return info, ok
+}
@@ -0,0 +1,38 @@
+package pm
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+func TestContextWithMessage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns the message and the subscription info attached to the context", func(t *testing.T) {
+		t.Parallel()
+
+		info := &SubscriptionInfo{SubscriptionID: "test-sub"}
+		m := &pubsub.Message{ID: "message-id"}
+		ctx := ContextWithMessage(context.Background(), info, m)
+
+		if got, ok := MessageFromContext(ctx); !ok || got != m {
+			t.Errorf("MessageFromContext() got: %v, want: %v", got, m)
+		}
+		if got, ok := SubscriptionInfoFromContext(ctx); !ok || got != info {
+			t.Errorf("SubscriptionInfoFromContext() got: %v, want: %v", got, info)
+		}
+	})
+
+	t.Run("returns false when nothing is attached to the context", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := MessageFromContext(context.Background()); ok {
+			t.Error("MessageFromContext() is expected to return false")
+		}
+		if _, ok := SubscriptionInfoFromContext(context.Background()); ok {
+			t.Error("SubscriptionInfoFromContext() is expected to return false")
+		}
+	})
+}
@@ -0,0 +1,343 @@
+// Package deadletter provides a way to replay messages in a dead-letter subscription to their original topic.
+//
+// Example usage:
+//
+//	publisher := pm.NewPublisher(client)
+//	result, err := deadletter.Replay(
+//		ctx,
+//		publisher,
+//		client.Subscriber("example-topic-dead-letter-sub"),
+//		deadletter.WithMaxAge(24*time.Hour),
+//		deadletter.WithRateLimit(100),
+//	)
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/zero-color/pm"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// SourceTopicAttribute is the attribute to specify the original topic explicitly.
+	// pm doesn't set it, so callers must set it when they publish to the dead-letter topic by themselves.
+	// It takes priority over the attributes added by Pub/Sub dead-lettering.
+	SourceTopicAttribute = "pm_source_topic"
+
+	// SourceSubscriptionAttribute is the attribute added by Pub/Sub dead-lettering.
+	SourceSubscriptionAttribute = "CloudPubSubDeadLetterSourceSubscription"
+	// SourceSubscriptionProjectAttribute is the attribute added by Pub/Sub dead-lettering.
+	SourceSubscriptionProjectAttribute = "CloudPubSubDeadLetterSourceSubscriptionProject"
+	// SourceTopicPublishTimeAttribute is the attribute added by Pub/Sub dead-lettering.
+	SourceTopicPublishTimeAttribute = "CloudPubSubDeadLetterSourceTopicPublishTime"
+
+	deadLetterAttributePrefix = "CloudPubSubDeadLetter"
+
+	defaultIdleTimeout = 10 * time.Second
+)
+
+// ErrMaxOutstandingMessages is returned by Replay when the held messages reach ReceiveSettings.MaxOutstandingMessages
+// of the subscription, since no more message is delivered and the rest of the subscription may not be replayed.
+var ErrMaxOutstandingMessages = errors.New("deadletter: held messages reached ReceiveSettings.MaxOutstandingMessages")
+
+// Result contains the number of messages handled by Replay.
+type Result struct {
+	// Replayed is the number of messages republished to the original topic.
+	// In dry-run mode, it is the number of messages which would be republished.
+	Replayed int
+	// Skipped is the number of messages which didn't pass the filters.
+	Skipped int
+	// Failed is the number of messages which couldn't be republished.
+	Failed int
+}
+
+// Replay drains the dead-letter subscription and republishes each message to its original topic.
+// Replayed messages are acked. Skipped, failed and dry-run messages are held unacked until Replay returns,
+// and then nacked to be left in the subscription, so that they aren't redelivered during the replay.
+// The held messages count toward ReceiveSettings.MaxOutstandingMessages of the subscription,
+// so set it large enough to hold the messages which are not replayed. Otherwise ErrMaxOutstandingMessages is returned.
+// Replay returns when no new message arrives and no message is being replayed within the idle timeout,
+// or the context is done. Messages whose replay is interrupted by the context are counted as failed.
+func Replay(ctx context.Context, publisher *pm.Publisher, subscription *pubsub.Subscriber, opt ...Option) (*Result, error) {
+	opts := &options{
+		idleTimeout: defaultIdleTimeout,
+		now:         time.Now,
+	}
+	for _, o := range opt {
+		o.apply(opts)
+	}
+	if opts.topicResolver == nil {
+		opts.topicResolver = newDefaultTopicResolver(publisher.Client)
+	}
+
+	maxOutstanding := subscription.ReceiveSettings.MaxOutstandingMessages
+	if maxOutstanding == 0 {
+		maxOutstanding = pubsub.DefaultReceiveSettings.MaxOutstandingMessages
+	}
+	r := &replayer{
+		opts:           opts,
+		publisher:      publisher,
+		maxOutstanding: maxOutstanding,
+		release:        make(chan struct{}),
+		publishers:     map[string]*pubsub.Publisher{},
+		seen:           map[string]struct{}{},
+		lastActive:     opts.now(),
+		result:         &Result{},
+	}
+	if opts.ratePerSecond > 0 {
+		r.limiter = rate.NewLimiter(rate.Limit(opts.ratePerSecond), 1)
+	}
+	defer r.stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go r.cancelOnIdle(ctx, cancel)
+
+	err := subscription.Receive(ctx, r.handle)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+	if r.outstandingFull {
+		r.errs = append(r.errs, ErrMaxOutstandingMessages)
+	}
+	return r.result, errors.Join(r.errs...)
+}
+
+type replayer struct {
+	opts           *options
+	publisher      *pm.Publisher
+	limiter        *rate.Limiter
+	maxOutstanding int
+	// release is closed when the replay finishes to nack the held messages.
+	release chan struct{}
+	holding sync.WaitGroup
+
+	mu              sync.Mutex
+	publishers      map[string]*pubsub.Publisher
+	seen            map[string]struct{}
+	inFlight        int
+	lastActive      time.Time
+	held            int
+	released        bool
+	outstandingFull bool
+	result          *Result
+	errs            []error
+}
+
+func (r *replayer) handle(ctx context.Context, m *pubsub.Message) {
+	r.mu.Lock()
+	if _, ok := r.seen[m.ID]; ok {
+		// messages whose ack deadline expired are redelivered, so they are not regarded as new.
+		r.mu.Unlock()
+		r.hold(ctx, m)
+		return
+	}
+	r.seen[m.ID] = struct{}{}
+	r.inFlight++
+	r.lastActive = r.opts.now()
+	r.mu.Unlock()
+
+	if r.replay(ctx, m) {
+		m.Ack()
+		return
+	}
+	r.hold(ctx, m)
+}
+
+// replay republishes the message, and reports whether the message should be acked.
+func (r *replayer) replay(ctx context.Context, m *pubsub.Message) bool {
+	defer func() {
+		// the time spent in replaying the message doesn't count toward the idle timeout.
+		r.mu.Lock()
+		r.inFlight--
+		r.lastActive = r.opts.now()
+		r.mu.Unlock()
+	}()
+
+	for _, f := range r.opts.filters {
+		if !f(m) {
+			r.record(func(res *Result) { res.Skipped++ }, nil)
+			return false
+		}
+	}
+
+	topic, err := r.opts.topicResolver(ctx, m)
+	if err != nil {
+		r.record(func(res *Result) { res.Failed++ }, fmt.Errorf("resolve topic of message '%s': %w", m.ID, err))
+		return false
+	}
+
+	msg := &pubsub.Message{
+		Data:        m.Data,
+		Attributes:  r.stripAttributes(m.Attributes),
+		OrderingKey: m.OrderingKey,
+	}
+
+	if r.opts.dryRunWriter != nil {
+		r.mu.Lock()
+		fmt.Fprintf(r.opts.dryRunWriter, "message '%s' would be replayed to '%s' with attributes %v\n", m.ID, topic, msg.Attributes)
+		r.mu.Unlock()
+		r.record(func(res *Result) { res.Replayed++ }, nil)
+		return false
+	}
+
+	if r.limiter != nil {
+		if err := r.limiter.Wait(ctx); err != nil {
+			r.record(func(res *Result) { res.Failed++ }, fmt.Errorf("wait for the rate limit to replay message '%s': %w", m.ID, err))
+			return false
+		}
+	}
+
+	if _, err := r.publisher.Publish(ctx, r.topicPublisher(topic), msg).Get(ctx); err != nil {
+		r.record(func(res *Result) { res.Failed++ }, fmt.Errorf("replay message '%s' to '%s': %w", m.ID, topic, err))
+		return false
+	}
+	r.record(func(res *Result) { res.Replayed++ }, nil)
+	return true
+}
+
+// hold keeps the message unacked until the replay finishes, and then nacks it.
+// Nacking it right away makes Pub/Sub redeliver it immediately over and over during the replay.
+func (r *replayer) hold(ctx context.Context, m *pubsub.Message) {
+	r.mu.Lock()
+	if r.released {
+		r.mu.Unlock()
+		m.Nack()
+		return
+	}
+	r.held++
+	if r.maxOutstanding > 0 && r.held >= r.maxOutstanding {
+		r.outstandingFull = true
+	}
+	r.holding.Add(1)
+	r.mu.Unlock()
+	defer r.holding.Done()
+
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+	}
+	m.Nack()
+}
+
+func (r *replayer) record(f func(*Result), err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f(r.result)
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+}
+
+func (r *replayer) topicPublisher(topic string) *pubsub.Publisher {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.publishers[topic]
+	if !ok {
+		p = r.publisher.Client.Publisher(topic)
+		r.publishers[topic] = p
+	}
+	return p
+}
+
+func (r *replayer) stripAttributes(attrs map[string]string) map[string]string {
+	stripped := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		if strings.HasPrefix(k, deadLetterAttributePrefix) || k == SourceTopicAttribute {
+			continue
+		}
+		stripped[k] = v
+	}
+	for _, k := range r.opts.stripAttributes {
+		delete(stripped, k)
+	}
+	return stripped
+}
+
+func (r *replayer) cancelOnIdle(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(max(r.opts.idleTimeout/10, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			idle := r.inFlight == 0 && r.opts.now().Sub(r.lastActive) >= r.opts.idleTimeout
+			if idle {
+				r.released = true
+			}
+			r.mu.Unlock()
+			if idle {
+				// nack the held messages before stopping Receive, so that the nacks are sent
+				// and the messages are redelivered right away to the next subscriber.
+				close(r.release)
+				r.holding.Wait()
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (r *replayer) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.publishers {
+		p.Stop()
+	}
+}
+
+// newDefaultTopicResolver returns a TopicResolver which resolves the topic from SourceTopicAttribute first,
+// and then from the source subscription recorded by Pub/Sub dead-lettering.
+func newDefaultTopicResolver(client *pubsub.Client) TopicResolver {
+	var mu sync.Mutex
+	topicBySubscription := map[string]string{}
+	return func(ctx context.Context, m *pubsub.Message) (string, error) {
+		if topic, ok := m.Attributes[SourceTopicAttribute]; ok {
+			return topic, nil
+		}
+		subID, ok := m.Attributes[SourceSubscriptionAttribute]
+		if !ok {
+			return "", fmt.Errorf("neither '%s' nor '%s' attribute is set", SourceTopicAttribute, SourceSubscriptionAttribute)
+		}
+		project, ok := m.Attributes[SourceSubscriptionProjectAttribute]
+		if !ok {
+			project = client.Project()
+		}
+		subName := fmt.Sprintf("projects/%s/subscriptions/%s", project, subID)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if topic, ok := topicBySubscription[subName]; ok {
+			return topic, nil
+		}
+		sub, err := client.SubscriptionAdminClient.GetSubscription(ctx, &pb.GetSubscriptionRequest{Subscription: subName})
+		if err != nil {
+			return "", err
+		}
+		topicBySubscription[subName] = sub.Topic
+		return sub.Topic, nil
+	}
+}
+
+// originalPublishTime returns the publish time to the original topic if it's recorded by Pub/Sub dead-lettering.
+func originalPublishTime(m *pubsub.Message) time.Time {
+	if v, ok := m.Attributes[SourceTopicPublishTimeAttribute]; ok {
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	}
+	return m.PublishTime
+}
@@ -0,0 +1,344 @@
+package deadletter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/zero-color/pm"
+)
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	t.Cleanup(func() { _ = ts.Close() })
+
+	createTopic := func(t *testing.T, name string) *pb.Topic {
+		t.Helper()
+		topicPb, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{
+			Name: fmt.Sprintf("projects/test-project/topics/%s", name),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return topicPb
+	}
+	createSubscription := func(t *testing.T, name string, topic *pb.Topic) *pb.Subscription {
+		t.Helper()
+		subPb, err := ts.Client.SubscriptionAdminClient.CreateSubscription(ctx, &pb.Subscription{
+			Name:  fmt.Sprintf("projects/test-project/subscriptions/%s", name),
+			Topic: topic.Name,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return subPb
+	}
+	// receiveN receives messages until n messages arrive or the timeout. n <= 0 receives until the timeout.
+	receiveN := func(t *testing.T, sub *pubsub.Subscriber, n int, timeout time.Duration) []*pubsub.Message {
+		t.Helper()
+		var mu sync.Mutex
+		var messages []*pubsub.Message
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		err := sub.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+			m.Ack()
+			mu.Lock()
+			messages = append(messages, m)
+			if n > 0 && len(messages) >= n {
+				cancel()
+			}
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return messages
+	}
+	receiveAll := func(t *testing.T, sub *pubsub.Subscriber) []*pubsub.Message {
+		t.Helper()
+		return receiveN(t, sub, -1, 1*time.Second)
+	}
+
+	t.Run("replays dead-lettered messages to the topic of the source subscription", func(t *testing.T) {
+		t.Parallel()
+
+		name := fmt.Sprintf("TestReplay_replay_%d", time.Now().UnixNano())
+		sourceTopic := createTopic(t, name)
+		sourceSub := createSubscription(t, name, sourceTopic)
+		dlqTopic := createTopic(t, name+"_dlq")
+		dlqSub := createSubscription(t, name+"_dlq", dlqTopic)
+
+		dlqPublisher := ts.Client.Publisher(dlqTopic.Name)
+		defer dlqPublisher.Stop()
+		for _, data := range []string{"replay", "skip"} {
+			_, err := dlqPublisher.Publish(ctx, &pubsub.Message{
+				Data: []byte(data),
+				Attributes: map[string]string{
+					SourceSubscriptionAttribute:                name,
+					SourceSubscriptionProjectAttribute:         "test-project",
+					"CloudPubSubDeadLetterSourceDeliveryCount": "5",
+					"type":  data,
+					"error": "something went wrong",
+				},
+			}).Get(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := Replay(
+			ctx,
+			pm.NewPublisher(ts.Client),
+			ts.Client.Subscriber(dlqSub.Name),
+			WithAttributeFilter("type", "replay"),
+			WithStripAttributes("error"),
+			WithRateLimit(10),
+			WithIdleTimeout(500*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("Replay() returned err: %v", err)
+		}
+		if result.Replayed != 1 || result.Skipped != 1 || result.Failed != 0 {
+			t.Errorf("Replay() result: %+v, want: replayed 1, skipped 1, failed 0", result)
+		}
+
+		messages := receiveAll(t, ts.Client.Subscriber(sourceSub.Name))
+		if len(messages) != 1 {
+			t.Fatalf("Only 1 message is expected to be replayed, got: %v", len(messages))
+		}
+		if got := string(messages[0].Data); got != "replay" {
+			t.Errorf("Replayed message data: got: %v, want: %v", got, "replay")
+		}
+		wantAttrs := map[string]string{"type": "replay"}
+		if got := messages[0].Attributes; fmt.Sprint(got) != fmt.Sprint(wantAttrs) {
+			t.Errorf("Replayed message attributes: got: %v, want: %v", got, wantAttrs)
+		}
+
+		// the skipped message is nacked before Replay returns, so it's redelivered right away.
+		left := receiveN(t, ts.Client.Subscriber(dlqSub.Name), 1, 10*time.Second)
+		if len(left) != 1 || string(left[0].Data) != "skip" {
+			t.Errorf("The skipped message is expected to be left in the dead-letter subscription, got: %v messages", len(left))
+		}
+	})
+
+	t.Run("doesn't replay messages in dry-run mode", func(t *testing.T) {
+		t.Parallel()
+
+		name := fmt.Sprintf("TestReplay_dry_run_%d", time.Now().UnixNano())
+		sourceTopic := createTopic(t, name)
+		sourceSub := createSubscription(t, name, sourceTopic)
+		dlqTopic := createTopic(t, name+"_dlq")
+		dlqSub := createSubscription(t, name+"_dlq", dlqTopic)
+
+		dlqPublisher := ts.Client.Publisher(dlqTopic.Name)
+		defer dlqPublisher.Stop()
+		_, err := dlqPublisher.Publish(ctx, &pubsub.Message{
+			Data:       []byte("test"),
+			Attributes: map[string]string{SourceTopicAttribute: sourceTopic.Name},
+		}).Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		result, err := Replay(
+			ctx,
+			pm.NewPublisher(ts.Client),
+			ts.Client.Subscriber(dlqSub.Name),
+			WithDryRun(&buf),
+			WithIdleTimeout(500*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("Replay() returned err: %v", err)
+		}
+		if result.Replayed != 1 {
+			t.Errorf("Replay() result: %+v, want: replayed 1", result)
+		}
+		if !strings.Contains(buf.String(), sourceTopic.Name) {
+			t.Errorf("Dry-run output must contain the topic, got: %v", buf.String())
+		}
+		if messages := receiveAll(t, ts.Client.Subscriber(sourceSub.Name)); len(messages) != 0 {
+			t.Errorf("No message is expected to be replayed in dry-run mode, got: %v", len(messages))
+		}
+	})
+
+	t.Run("fails when the original topic can't be resolved", func(t *testing.T) {
+		t.Parallel()
+
+		name := fmt.Sprintf("TestReplay_unresolved_%d", time.Now().UnixNano())
+		dlqTopic := createTopic(t, name+"_dlq")
+		dlqSub := createSubscription(t, name+"_dlq", dlqTopic)
+
+		dlqPublisher := ts.Client.Publisher(dlqTopic.Name)
+		defer dlqPublisher.Stop()
+		_, err := dlqPublisher.Publish(ctx, &pubsub.Message{Data: []byte("test")}).Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Replay(
+			ctx,
+			pm.NewPublisher(ts.Client),
+			ts.Client.Subscriber(dlqSub.Name),
+			WithIdleTimeout(500*time.Millisecond),
+		)
+		if err == nil {
+			t.Error("Replay() is expected to return err, but got nil")
+		}
+		if result.Failed != 1 {
+			t.Errorf("Replay() result: %+v, want: failed 1", result)
+		}
+	})
+}
+
+func TestReplay_limits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	t.Cleanup(func() { _ = ts.Close() })
+
+	setup := func(t *testing.T, name string, n int) (*pb.Topic, *pb.Subscription) {
+		t.Helper()
+		sourceTopic, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{Name: fmt.Sprintf("projects/test-project/topics/%s", name)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dlqTopic, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{Name: fmt.Sprintf("projects/test-project/topics/%s_dlq", name)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dlqSub, err := ts.Client.SubscriptionAdminClient.CreateSubscription(ctx, &pb.Subscription{
+			Name:  fmt.Sprintf("projects/test-project/subscriptions/%s_dlq", name),
+			Topic: dlqTopic.Name,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dlqPublisher := ts.Client.Publisher(dlqTopic.Name)
+		defer dlqPublisher.Stop()
+		for i := range n {
+			_, err := dlqPublisher.Publish(ctx, &pubsub.Message{
+				Data:       []byte(fmt.Sprint(i)),
+				Attributes: map[string]string{SourceTopicAttribute: sourceTopic.Name},
+			}).Get(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return sourceTopic, dlqSub
+	}
+
+	t.Run("waiting for the rate limit doesn't count toward the idle timeout", func(t *testing.T) {
+		t.Parallel()
+
+		_, dlqSub := setup(t, fmt.Sprintf("TestReplay_limits_wait_%d", time.Now().UnixNano()), 3)
+		result, err := Replay(
+			ctx,
+			pm.NewPublisher(ts.Client),
+			ts.Client.Subscriber(dlqSub.Name),
+			WithRateLimit(2),
+			WithIdleTimeout(200*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("Replay() returned err: %v", err)
+		}
+		if result.Replayed != 3 || result.Failed != 0 {
+			t.Errorf("Replay() result: %+v, want: replayed 3, failed 0", result)
+		}
+	})
+
+	t.Run("messages which can't wait for the rate limit are counted as failed", func(t *testing.T) {
+		t.Parallel()
+
+		_, dlqSub := setup(t, fmt.Sprintf("TestReplay_limits_canceled_%d", time.Now().UnixNano()), 2)
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		result, err := Replay(
+			ctx,
+			pm.NewPublisher(ts.Client),
+			ts.Client.Subscriber(dlqSub.Name),
+			WithRateLimit(0.001),
+			WithIdleTimeout(500*time.Millisecond),
+		)
+		if err == nil {
+			t.Error("Replay() is expected to return err, but got nil")
+		}
+		if result.Replayed != 1 || result.Failed != 1 {
+			t.Errorf("Replay() result: %+v, want: replayed 1, failed 1", result)
+		}
+	})
+
+	t.Run("returns ErrMaxOutstandingMessages when the held messages reach the limit", func(t *testing.T) {
+		t.Parallel()
+
+		_, dlqSub := setup(t, fmt.Sprintf("TestReplay_limits_outstanding_%d", time.Now().UnixNano()), 2)
+		sub := ts.Client.Subscriber(dlqSub.Name)
+		sub.ReceiveSettings.MaxOutstandingMessages = 1
+		result, err := Replay(
+			ctx,
+			pm.NewPublisher(ts.Client),
+			sub,
+			WithFilter(func(m *pubsub.Message) bool { return false }),
+			WithIdleTimeout(500*time.Millisecond),
+		)
+		if !errors.Is(err, ErrMaxOutstandingMessages) {
+			t.Errorf("Replay() is expected to return ErrMaxOutstandingMessages, but got err: %v", err)
+		}
+		// the client doesn't strictly keep the flow control, so more messages than the limit can be delivered.
+		if result.Skipped == 0 {
+			t.Errorf("Replay() result: %+v, want: skipped at least 1", result)
+		}
+	})
+}
+
+func TestWithMaxAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	opts := &options{now: func() time.Time { return now }}
+	WithMaxAge(24 * time.Hour).apply(opts)
+	filter := opts.filters[0]
+
+	tests := []struct {
+		name string
+		m    *pubsub.Message
+		want bool
+	}{
+		{
+			name: "message published within max age passes",
+			m:    &pubsub.Message{PublishTime: now.Add(-1 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "message published before max age doesn't pass",
+			m:    &pubsub.Message{PublishTime: now.Add(-48 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "original publish time is prioritised",
+			m: &pubsub.Message{
+				PublishTime: now.Add(-1 * time.Hour),
+				Attributes:  map[string]string{SourceTopicPublishTimeAttribute: now.Add(-48 * time.Hour).Format(time.RFC3339Nano)},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter(tt.m); got != tt.want {
+				t.Errorf("WithMaxAge() filter got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
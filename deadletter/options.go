@@ -0,0 +1,115 @@
+package deadletter
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+// TopicResolver resolves the topic name or ID which the dead-lettered message is replayed to.
+type TopicResolver func(ctx context.Context, m *pubsub.Message) (string, error)
+
+// Filter decides whether the dead-lettered message should be replayed.
+type Filter func(m *pubsub.Message) bool
+
+type options struct {
+	topicResolver   TopicResolver
+	filters         []Filter
+	ratePerSecond   float64
+	dryRunWriter    io.Writer
+	stripAttributes []string
+	idleTimeout     time.Duration
+	now             func() time.Time
+}
+
+// Option is a option to change replay configuration.
+type Option interface {
+	apply(*options)
+}
+
+type OptionFunc struct {
+	f func(*options)
+}
+
+func (s *OptionFunc) apply(so *options) {
+	s.f(so)
+}
+
+func newOptionFunc(f func(*options)) *OptionFunc {
+	return &OptionFunc{
+		f: f,
+	}
+}
+
+// WithTopicResolver customizes the function for resolving the original topic of a message.
+func WithTopicResolver(f TopicResolver) Option {
+	return newOptionFunc(func(o *options) {
+		o.topicResolver = f
+	})
+}
+
+// WithFilter adds a filter. Only messages which pass all the filters are replayed.
+func WithFilter(f Filter) Option {
+	return newOptionFunc(func(o *options) {
+		o.filters = append(o.filters, f)
+	})
+}
+
+// WithAttributeFilter replays only messages which have the attribute with the given value.
+func WithAttributeFilter(key, value string) Option {
+	return WithFilter(func(m *pubsub.Message) bool {
+		v, ok := m.Attributes[key]
+		return ok && v == value
+	})
+}
+
+// WithMaxAge replays only messages originally published within the given duration.
+func WithMaxAge(d time.Duration) Option {
+	return newOptionFunc(func(o *options) {
+		o.filters = append(o.filters, func(m *pubsub.Message) bool {
+			return o.now().Sub(originalPublishTime(m)) <= d
+		})
+	})
+}
+
+// WithMinAge replays only messages originally published at least the given duration ago.
+func WithMinAge(d time.Duration) Option {
+	return newOptionFunc(func(o *options) {
+		o.filters = append(o.filters, func(m *pubsub.Message) bool {
+			return o.now().Sub(originalPublishTime(m)) >= d
+		})
+	})
+}
+
+// WithRateLimit limits the number of republished messages per second.
+func WithRateLimit(perSecond float64) Option {
+	return newOptionFunc(func(o *options) {
+		o.ratePerSecond = perSecond
+	})
+}
+
+// WithDryRun writes the messages which would be replayed to w instead of republishing them.
+// The dead-lettered messages are left in the subscription.
+func WithDryRun(w io.Writer) Option {
+	return newOptionFunc(func(o *options) {
+		o.dryRunWriter = w
+	})
+}
+
+// WithStripAttributes removes the given attributes in addition to the dead-letter attributes
+// before republishing.
+func WithStripAttributes(keys ...string) Option {
+	return newOptionFunc(func(o *options) {
+		o.stripAttributes = append(o.stripAttributes, keys...)
+	})
+}
+
+// WithIdleTimeout customizes how long Replay waits for a new message before it regards
+// the subscription as drained.
+func WithIdleTimeout(d time.Duration) Option {
+	return newOptionFunc(func(o *options) {
+		o.idleTimeout = d
+	})
+}
@@ -0,0 +1,41 @@
+// Package jsonkey extracts keys from the fields of JSON payloads.
+package jsonkey
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Lookup returns the value of the field of the JSON data as a string.
+// Nested fields can be specified with multiple field names.
+// Numbers are returned as written in the data, so that large integers don't lose their precision.
+// When the field is not set or null, it returns an empty string.
+func Lookup(data []byte, fields ...string) (string, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return "", err
+	}
+	for _, f := range fields {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return "", nil
+		}
+		v = obj[f]
+	}
+	switch key := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return key, nil
+	case json.Number:
+		return key.String(), nil
+	default:
+		b, err := json.Marshal(key)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
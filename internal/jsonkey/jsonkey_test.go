@@ -0,0 +1,36 @@
+package jsonkey
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fields  []string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "string field", fields: []string{"id"}, data: `{"id":"a"}`, want: "a"},
+		{name: "nested number field", fields: []string{"event", "id"}, data: `{"event":{"id":1}}`, want: "1"},
+		{name: "integer beyond the float64 precision", fields: []string{"id"}, data: `{"id":1234567890123456789}`, want: "1234567890123456789"},
+		{name: "adjacent integer beyond the float64 precision", fields: []string{"id"}, data: `{"id":1234567890123456790}`, want: "1234567890123456790"},
+		{name: "boolean field", fields: []string{"id"}, data: `{"id":true}`, want: "true"},
+		{name: "null field", fields: []string{"id"}, data: `{"id":null}`, want: ""},
+		{name: "missing field", fields: []string{"id"}, data: `{}`, want: ""},
+		{name: "field of non-object", fields: []string{"event", "id"}, data: `{"event":"a"}`, want: ""},
+		{name: "invalid JSON", fields: []string{"id"}, data: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lookup([]byte(tt.data), tt.fields...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup() err: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Lookup() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
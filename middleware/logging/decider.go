@@ -1,6 +1,13 @@
 package pm_logging
 
-import "github.com/zero-color/pm"
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
 
 // LogDecider function defines rules for suppressing any interceptor logs
 type LogDecider func(info *pm.SubscriptionInfo, err error) bool
@@ -10,3 +17,73 @@ type LogDecider func(info *pm.SubscriptionInfo, err error) bool
 func DefaultLogDecider(_ *pm.SubscriptionInfo, _ error) bool {
 	return true
 }
+
+// PublishLogDecider function defines rules for suppressing any publish interceptor logs
+type PublishLogDecider func(topicID string, err error) bool
+
+// DefaultPublishLogDecider is the default implementation of decider to see if you should log the publish
+// by default this if always true so all publishing are logged
+func DefaultPublishLogDecider(_ string, _ error) bool {
+	return true
+}
+
+// MessageLogDecider function defines rules for suppressing any interceptor logs
+// based on the message and how long the processing took.
+// It's checked in addition to LogDecider.
+type MessageLogDecider func(info *pm.SubscriptionInfo, m *pubsub.Message, err error, duration time.Duration) bool
+
+// DefaultMessageLogDecider is the default implementation of MessageLogDecider
+// by default this if always true so all processing are logged
+func DefaultMessageLogDecider(_ *pm.SubscriptionInfo, _ *pubsub.Message, _ error, _ time.Duration) bool {
+	return true
+}
+
+// SamplingDecider logs successful processing with the given probability between 0 and 1.
+// Failed processing is always logged.
+func SamplingDecider(rate float64) MessageLogDecider {
+	return func(_ *pm.SubscriptionInfo, _ *pubsub.Message, err error, _ time.Duration) bool {
+		return err != nil || rand.Float64() < rate
+	}
+}
+
+// SlowerThanDecider logs processing which took longer than the threshold.
+// Failed processing is always logged.
+func SlowerThanDecider(threshold time.Duration) MessageLogDecider {
+	return func(_ *pm.SubscriptionInfo, _ *pubsub.Message, err error, duration time.Duration) bool {
+		return err != nil || duration > threshold
+	}
+}
+
+// FirstNDecider logs the first n successful processing per subscription in each interval,
+// and then every m-th processing in the rest of the interval. m 0 drops all the rest.
+// Failed processing is always logged.
+func FirstNDecider(n, m int, interval time.Duration) MessageLogDecider {
+	return newFirstNDecider(n, m, interval, time.Now)
+}
+
+func newFirstNDecider(n, m int, interval time.Duration, now func() time.Time) MessageLogDecider {
+	type counter struct {
+		resetAt time.Time
+		count   int
+	}
+	var mu sync.Mutex
+	counters := map[string]*counter{}
+	return func(info *pm.SubscriptionInfo, _ *pubsub.Message, err error, _ time.Duration) bool {
+		if err != nil {
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		t := now()
+		c, ok := counters[info.SubscriptionID]
+		if !ok || !t.Before(c.resetAt) {
+			c = &counter{resetAt: t.Add(interval)}
+			counters[info.SubscriptionID] = c
+		}
+		c.count++
+		if c.count <= n {
+			return true
+		}
+		return m > 0 && (c.count-n)%m == 0
+	}
+}
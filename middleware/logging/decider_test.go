@@ -1,7 +1,11 @@
 package pm_logging
 
 import (
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/zero-color/pm"
 )
 
 func TestDefaultDecider(t *testing.T) {
@@ -11,3 +15,92 @@ func TestDefaultDecider(t *testing.T) {
 		t.Errorf("DefaultLogDecider() = %v, want %v", got, true)
 	}
 }
+
+func TestDefaultPublishLogDecider(t *testing.T) {
+	t.Parallel()
+
+	if got := DefaultPublishLogDecider("", nil); got != true {
+		t.Errorf("DefaultPublishLogDecider() = %v, want %v", got, true)
+	}
+}
+
+func TestDefaultMessageLogDecider(t *testing.T) {
+	t.Parallel()
+
+	if got := DefaultMessageLogDecider(nil, nil, nil, 0); got != true {
+		t.Errorf("DefaultMessageLogDecider() = %v, want %v", got, true)
+	}
+}
+
+func TestSamplingDecider(t *testing.T) {
+	t.Parallel()
+
+	info := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+
+	if got := SamplingDecider(0)(info, nil, nil, 0); got != false {
+		t.Errorf("SamplingDecider(0) = %v, want %v", got, false)
+	}
+	if got := SamplingDecider(1)(info, nil, nil, 0); got != true {
+		t.Errorf("SamplingDecider(1) = %v, want %v", got, true)
+	}
+	if got := SamplingDecider(0)(info, nil, errors.New("error"), 0); got != true {
+		t.Errorf("SamplingDecider(0) is expected to log errors, got: %v, want %v", got, true)
+	}
+}
+
+func TestSlowerThanDecider(t *testing.T) {
+	t.Parallel()
+
+	info := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+	decider := SlowerThanDecider(time.Second)
+
+	tests := []struct {
+		name     string
+		err      error
+		duration time.Duration
+		want     bool
+	}{
+		{name: "fast processing isn't logged", duration: 500 * time.Millisecond, want: false},
+		{name: "slow processing is logged", duration: 2 * time.Second, want: true},
+		{name: "failed processing is logged", err: errors.New("error"), duration: 0, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decider(info, nil, tt.err, tt.duration); got != tt.want {
+				t.Errorf("SlowerThanDecider() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstNDecider(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	decider := newFirstNDecider(2, 3, time.Minute, func() time.Time { return now })
+	sub1 := &pm.SubscriptionInfo{SubscriptionID: "sub1"}
+	sub2 := &pm.SubscriptionInfo{SubscriptionID: "sub2"}
+
+	var got []bool
+	for i := 0; i < 8; i++ {
+		got = append(got, decider(sub1, nil, nil, 0))
+	}
+	want := []bool{true, true, false, false, true, false, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("FirstNDecider() results = %v, want %v", got, want)
+		}
+	}
+
+	if !decider(sub2, nil, nil, 0) {
+		t.Errorf("FirstNDecider() is expected to count per subscription")
+	}
+	if !decider(sub1, nil, errors.New("error"), 0) {
+		t.Errorf("FirstNDecider() is expected to log errors")
+	}
+
+	now = now.Add(time.Minute)
+	if !decider(sub1, nil, nil, 0) {
+		t.Errorf("FirstNDecider() is expected to reset the count after the interval")
+	}
+}
@@ -0,0 +1,164 @@
+package pm_logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+// RedactedValue replaces redacted attribute values and payload fields in logs.
+const RedactedValue = "[REDACTED]"
+
+// Field is a key-value pair added to the log of a message.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// MessageFields configures which fields of a message are logged and how they are redacted.
+// The zero value logs no message field.
+type MessageFields struct {
+	// MessageID logs the message ID as pubsub.message_id.
+	MessageID bool
+	// PublishTime logs the publish time as pubsub.publish_time.
+	PublishTime bool
+	// OrderingKey logs the ordering key as pubsub.ordering_key.
+	OrderingKey bool
+	// DeliveryAttempt logs the delivery attempt as pubsub.delivery_attempt.
+	// It's only available when the subscription has a dead-letter policy.
+	DeliveryAttempt bool
+	// Attributes logs the attributes with the given keys as pubsub.attributes.<key>.
+	Attributes []string
+	// AllAttributes logs all the attributes as pubsub.attributes.<key>.
+	AllAttributes bool
+	// Payload logs the message data as pubsub.payload.
+	Payload bool
+	// MaxPayloadSize truncates the logged payload to the given bytes. 0 means no truncation.
+	MaxPayloadSize int
+	// RedactedAttributes is the denylist of attributes whose values are replaced with RedactedValue.
+	RedactedAttributes []string
+	// PayloadMasks are JSON paths such as "$.user.email" or "$.items[*].card" whose values are replaced
+	// with RedactedValue. When it's set and the payload isn't JSON, the whole payload is redacted.
+	PayloadMasks []string
+}
+
+// MetadataFields returns the fields for the message ID, publish time, ordering key and delivery attempt.
+// Empty values are omitted.
+func (f *MessageFields) MetadataFields(m *pubsub.Message, timestampFormat string) []Field {
+	var fields []Field
+	if f.MessageID && m.ID != "" {
+		fields = append(fields, Field{Key: "pubsub.message_id", Value: m.ID})
+	}
+	if f.PublishTime && !m.PublishTime.IsZero() {
+		fields = append(fields, Field{Key: "pubsub.publish_time", Value: m.PublishTime.Format(timestampFormat)})
+	}
+	if f.OrderingKey && m.OrderingKey != "" {
+		fields = append(fields, Field{Key: "pubsub.ordering_key", Value: m.OrderingKey})
+	}
+	if f.DeliveryAttempt && m.DeliveryAttempt != nil {
+		fields = append(fields, Field{Key: "pubsub.delivery_attempt", Value: *m.DeliveryAttempt})
+	}
+	return fields
+}
+
+// ContentFields returns the fields for the attributes and the payload with the redaction rules applied.
+func (f *MessageFields) ContentFields(m *pubsub.Message) []Field {
+	var fields []Field
+	keys := f.Attributes
+	if f.AllAttributes {
+		keys = make([]string, 0, len(m.Attributes))
+		for k := range m.Attributes {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+	}
+	for _, k := range keys {
+		v, ok := m.Attributes[k]
+		if !ok {
+			continue
+		}
+		if slices.Contains(f.RedactedAttributes, k) {
+			v = RedactedValue
+		}
+		fields = append(fields, Field{Key: "pubsub.attributes." + k, Value: v})
+	}
+	if f.Payload {
+		payload, truncated := f.payload(m.Data)
+		fields = append(fields, Field{Key: "pubsub.payload", Value: payload})
+		if truncated {
+			fields = append(fields, Field{Key: "pubsub.payload_truncated", Value: true})
+		}
+	}
+	return fields
+}
+
+func (f *MessageFields) payload(data []byte) (string, bool) {
+	if len(f.PayloadMasks) > 0 {
+		masked, err := maskJSON(data, f.PayloadMasks)
+		if err != nil {
+			return RedactedValue, false
+		}
+		data = masked
+	}
+	if f.MaxPayloadSize <= 0 || len(data) <= f.MaxPayloadSize {
+		return string(data), false
+	}
+	n := f.MaxPayloadSize
+	// don't cut a multi-byte character in the middle.
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return string(data[:n]), true
+}
+
+func maskJSON(data []byte, paths []string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		v = maskValue(v, parseJSONPath(p))
+	}
+	return json.Marshal(v)
+}
+
+func maskValue(v any, path []string) any {
+	if len(path) == 0 {
+		return RedactedValue
+	}
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, child := range vv {
+			if path[0] == "*" || path[0] == k {
+				vv[k] = maskValue(child, path[1:])
+			}
+		}
+	case []any:
+		for i, child := range vv {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				vv[i] = maskValue(child, path[1:])
+			}
+		}
+	}
+	return v
+}
+
+// parseJSONPath splits a JSON path like "$.items[0].name" into ["items", "0", "name"].
+func parseJSONPath(path string) []string {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	var segments []string
+	for _, s := range strings.Split(path, ".") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
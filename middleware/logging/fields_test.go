@@ -0,0 +1,132 @@
+package pm_logging
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+func TestMessageFields_MetadataFields(t *testing.T) {
+	t.Parallel()
+
+	deliveryAttempt := 3
+	publishTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := &pubsub.Message{
+		ID:              "message-id",
+		PublishTime:     publishTime,
+		OrderingKey:     "key",
+		DeliveryAttempt: &deliveryAttempt,
+	}
+
+	tests := []struct {
+		name   string
+		fields MessageFields
+		m      *pubsub.Message
+		want   []Field
+	}{
+		{
+			name:   "no field is returned by default",
+			fields: MessageFields{},
+			m:      m,
+			want:   nil,
+		},
+		{
+			name:   "all the enabled fields are returned",
+			fields: MessageFields{MessageID: true, PublishTime: true, OrderingKey: true, DeliveryAttempt: true},
+			m:      m,
+			want: []Field{
+				{Key: "pubsub.message_id", Value: "message-id"},
+				{Key: "pubsub.publish_time", Value: "2024-01-02T03:04:05Z"},
+				{Key: "pubsub.ordering_key", Value: "key"},
+				{Key: "pubsub.delivery_attempt", Value: 3},
+			},
+		},
+		{
+			name:   "empty values are omitted",
+			fields: MessageFields{MessageID: true, PublishTime: true, OrderingKey: true, DeliveryAttempt: true},
+			m:      &pubsub.Message{ID: "message-id"},
+			want:   []Field{{Key: "pubsub.message_id", Value: "message-id"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fields.MetadataFields(tt.m, time.RFC3339); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MetadataFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageFields_ContentFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		fields MessageFields
+		m      *pubsub.Message
+		want   []Field
+	}{
+		{
+			name:   "selected attributes are returned",
+			fields: MessageFields{Attributes: []string{"a", "missing"}},
+			m:      &pubsub.Message{Attributes: map[string]string{"a": "1", "b": "2"}},
+			want:   []Field{{Key: "pubsub.attributes.a", Value: "1"}},
+		},
+		{
+			name:   "denylisted attributes are redacted",
+			fields: MessageFields{AllAttributes: true, RedactedAttributes: []string{"token"}},
+			m:      &pubsub.Message{Attributes: map[string]string{"token": "secret", "type": "created"}},
+			want: []Field{
+				{Key: "pubsub.attributes.token", Value: RedactedValue},
+				{Key: "pubsub.attributes.type", Value: "created"},
+			},
+		},
+		{
+			name:   "payload is returned",
+			fields: MessageFields{Payload: true},
+			m:      &pubsub.Message{Data: []byte("hello")},
+			want:   []Field{{Key: "pubsub.payload", Value: "hello"}},
+		},
+		{
+			name:   "payload is truncated",
+			fields: MessageFields{Payload: true, MaxPayloadSize: 4},
+			m:      &pubsub.Message{Data: []byte("hello")},
+			want: []Field{
+				{Key: "pubsub.payload", Value: "hell"},
+				{Key: "pubsub.payload_truncated", Value: true},
+			},
+		},
+		{
+			name:   "payload is truncated at the rune boundary",
+			fields: MessageFields{Payload: true, MaxPayloadSize: 4},
+			m:      &pubsub.Message{Data: []byte("あい")},
+			want: []Field{
+				{Key: "pubsub.payload", Value: "あ"},
+				{Key: "pubsub.payload_truncated", Value: true},
+			},
+		},
+		{
+			name:   "JSON paths in payload are masked",
+			fields: MessageFields{Payload: true, PayloadMasks: []string{"$.user.email", "$.items[*].card", "$.missing"}},
+			m:      &pubsub.Message{Data: []byte(`{"user":{"email":"a@example.com","id":1},"items":[{"card":"4242","qty":2}]}`)},
+			want: []Field{
+				{Key: "pubsub.payload", Value: `{"items":[{"card":"[REDACTED]","qty":2}],"user":{"email":"[REDACTED]","id":1}}`},
+			},
+		},
+		{
+			name:   "non JSON payload is redacted entirely when masks are set",
+			fields: MessageFields{Payload: true, PayloadMasks: []string{"$.user.email"}},
+			m:      &pubsub.Message{Data: []byte("a@example.com")},
+			want:   []Field{{Key: "pubsub.payload", Value: RedactedValue}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fields.ContentFields(tt.m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ContentFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
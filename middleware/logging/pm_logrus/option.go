@@ -10,9 +10,12 @@ import (
 )
 
 type options struct {
-	shouldLog       pm_logging.LogDecider
-	messageProducer MessageProducer
-	timestampFormat string
+	shouldLog        pm_logging.LogDecider
+	shouldLogPublish pm_logging.PublishLogDecider
+	shouldLogMessage pm_logging.MessageLogDecider
+	messageProducer  MessageProducer
+	timestampFormat  string
+	messageFields    pm_logging.MessageFields
 }
 
 // MessageProducer produces a user defined log message
@@ -50,15 +53,103 @@ func newOptionFunc(f func(*options)) *OptionFunc {
 }
 
 // WithLogDecider customizes the function for deciding if the pm interceptor should log.
+// It only applies to the subscription interceptor. Use WithPublishLogDecider for the publish interceptor.
 func WithLogDecider(f pm_logging.LogDecider) Option {
 	return newOptionFunc(func(o *options) {
 		o.shouldLog = f
 	})
 }
 
+// WithMessageLogDecider customizes the function for deciding if the pm interceptor should log
+// with the message and the processing duration. It's checked in addition to the LogDecider.
+// It only applies to the subscription interceptor.
+func WithMessageLogDecider(f pm_logging.MessageLogDecider) Option {
+	return newOptionFunc(func(o *options) {
+		o.shouldLogMessage = f
+	})
+}
+
+// WithPublishLogDecider customizes the function for deciding if the pm publish interceptor should log.
+// It only applies to the publish interceptor.
+func WithPublishLogDecider(f pm_logging.PublishLogDecider) Option {
+	return newOptionFunc(func(o *options) {
+		o.shouldLogPublish = f
+	})
+}
+
 // WithMessageProducer customizes the function for logging.
 func WithMessageProducer(f MessageProducer) Option {
 	return newOptionFunc(func(o *options) {
 		o.messageProducer = f
 	})
 }
+
+// WithMessageID logs the message ID as pubsub.message_id.
+// The publish interceptor always logs the message ID assigned by the server.
+func WithMessageID() Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.MessageID = true
+	})
+}
+
+// WithPublishTime logs the publish time of the message as pubsub.publish_time.
+// The publish interceptor ignores it, since the publish time is set by the server.
+func WithPublishTime() Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.PublishTime = true
+	})
+}
+
+// WithOrderingKey logs the ordering key of the message as pubsub.ordering_key.
+// The publish interceptor always logs the ordering key.
+func WithOrderingKey() Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.OrderingKey = true
+	})
+}
+
+// WithDeliveryAttempt logs the delivery attempt of the message as pubsub.delivery_attempt.
+// The publish interceptor ignores it, since a published message has no delivery attempt.
+func WithDeliveryAttempt() Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.DeliveryAttempt = true
+	})
+}
+
+// WithAttributes logs the attributes with the given keys as pubsub.attributes.<key>.
+func WithAttributes(keys ...string) Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.Attributes = append(o.messageFields.Attributes, keys...)
+	})
+}
+
+// WithAllAttributes logs all the attributes as pubsub.attributes.<key>.
+func WithAllAttributes() Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.AllAttributes = true
+	})
+}
+
+// WithPayload logs the message data as pubsub.payload, truncated to maxSize bytes.
+// maxSize 0 means no truncation.
+func WithPayload(maxSize int) Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.Payload = true
+		o.messageFields.MaxPayloadSize = maxSize
+	})
+}
+
+// WithRedactedAttributes replaces the logged values of the given attributes with pm_logging.RedactedValue.
+func WithRedactedAttributes(keys ...string) Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.RedactedAttributes = append(o.messageFields.RedactedAttributes, keys...)
+	})
+}
+
+// WithPayloadMasks replaces the values at the given JSON paths such as "$.user.email" in the logged payload
+// with pm_logging.RedactedValue. The whole payload is redacted if it isn't JSON.
+func WithPayloadMasks(paths ...string) Option {
+	return newOptionFunc(func(o *options) {
+		o.messageFields.PayloadMasks = append(o.messageFields.PayloadMasks, paths...)
+	})
+}
@@ -15,9 +15,11 @@ import (
 // SubscriptionInterceptor returns a subscription interceptor that optionally logs the subscription process.
 func SubscriptionInterceptor(logger *logrus.Logger, opt ...Option) pm.SubscriptionInterceptor {
 	opts := &options{
-		shouldLog:       pm_logging.DefaultLogDecider,
-		messageProducer: DefaultMessageProducer,
-		timestampFormat: time.RFC3339,
+		shouldLog:        pm_logging.DefaultLogDecider,
+		shouldLogPublish: pm_logging.DefaultPublishLogDecider,
+		shouldLogMessage: pm_logging.DefaultMessageLogDecider,
+		messageProducer:  DefaultMessageProducer,
+		timestampFormat:  time.RFC3339,
 	}
 	for _, o := range opt {
 		o.apply(opts)
@@ -27,15 +29,17 @@ func SubscriptionInterceptor(logger *logrus.Logger, opt ...Option) pm.Subscripti
 		return func(ctx context.Context, m *pubsub.Message) error {
 			startTime := time.Now()
 			entry := logrus.NewEntry(logger)
-			newCtx := newLoggerForProcess(ctx, entry, info, startTime, opts.timestampFormat)
+			newCtx := newLoggerForProcess(ctx, entry, info, m, startTime, opts)
 
 			err := next(ctxlogrus.ToContext(newCtx, entry), m)
 
-			if opts.shouldLog(info, err) {
+			duration := time.Since(startTime)
+
+			if opts.shouldLog(info, err) && opts.shouldLogMessage(info, m, err, duration) {
 				opts.messageProducer(
 					newCtx, fmt.Sprintf("finished processing message '%s'", m.ID),
 					err,
-					time.Since(startTime),
+					duration,
 				)
 			}
 			return err
@@ -43,12 +47,76 @@ func SubscriptionInterceptor(logger *logrus.Logger, opt ...Option) pm.Subscripti
 	}
 }
 
-func newLoggerForProcess(ctx context.Context, entry *logrus.Entry, info *pm.SubscriptionInfo, start time.Time, timestampFormat string) context.Context {
+// PublishInterceptor returns a publish interceptor that optionally logs the publishing process.
+// The log is emitted once the PublishResult is ready, so the caller isn't blocked by logging.
+// WithLogDecider and WithMessageLogDecider are only for the subscription interceptor, so use WithPublishLogDecider
+// to suppress the logs. WithPublishTime and WithDeliveryAttempt are ignored, and the message ID assigned by the server
+// and the ordering key are always logged when they are set.
+func PublishInterceptor(logger *logrus.Logger, opt ...Option) pm.PublishInterceptor {
+	opts := &options{
+		shouldLog:        pm_logging.DefaultLogDecider,
+		shouldLogPublish: pm_logging.DefaultPublishLogDecider,
+		shouldLogMessage: pm_logging.DefaultMessageLogDecider,
+		messageProducer:  DefaultMessageProducer,
+		timestampFormat:  time.RFC3339,
+	}
+	for _, o := range opt {
+		o.apply(opts)
+	}
+
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			startTime := time.Now()
+			result := next(ctx, publisher, m)
+
+			go func() {
+				<-result.Ready()
+				duration := time.Since(startTime)
+				serverID, err := result.Get(ctx)
+				if !opts.shouldLogPublish(publisher.ID(), err) {
+					return
+				}
+				newCtx := newLoggerForPublish(ctx, logrus.NewEntry(logger), publisher, m, serverID, startTime, opts)
+				opts.messageProducer(
+					newCtx, fmt.Sprintf("finished publishing message to topic '%s'", publisher.ID()),
+					err,
+					duration,
+				)
+			}()
+			return result
+		}
+	}
+}
+
+func newLoggerForProcess(ctx context.Context, entry *logrus.Entry, info *pm.SubscriptionInfo, m *pubsub.Message, start time.Time, opts *options) context.Context {
 	fields := make(logrus.Fields, 0)
-	fields["pubsub.start_time"] = start.Format(timestampFormat)
+	fields["pubsub.start_time"] = start.Format(opts.timestampFormat)
 	if d, ok := ctx.Deadline(); ok {
-		fields["pubsub.deadline"] = d.Format(timestampFormat)
+		fields["pubsub.deadline"] = d.Format(opts.timestampFormat)
 	}
 	fields["pubsub.subscription_id"] = info.SubscriptionID
+	setMessageFields(fields, opts.messageFields.MetadataFields(m, opts.timestampFormat))
+	setMessageFields(fields, opts.messageFields.ContentFields(m))
+	return ctxlogrus.ToContext(ctx, entry.WithFields(fields))
+}
+
+func newLoggerForPublish(ctx context.Context, entry *logrus.Entry, publisher *pubsub.Publisher, m *pubsub.Message, serverID string, start time.Time, opts *options) context.Context {
+	fields := make(logrus.Fields, 0)
+	fields["pubsub.start_time"] = start.Format(opts.timestampFormat)
+	fields["pubsub.topic_id"] = publisher.ID()
+	if m.OrderingKey != "" {
+		fields["pubsub.ordering_key"] = m.OrderingKey
+	}
+	fields["pubsub.size"] = len(m.Data)
+	if serverID != "" {
+		fields["pubsub.message_id"] = serverID
+	}
+	setMessageFields(fields, opts.messageFields.ContentFields(m))
 	return ctxlogrus.ToContext(ctx, entry.WithFields(fields))
 }
+
+func setMessageFields(fields logrus.Fields, messageFields []pm_logging.Field) {
+	for _, f := range messageFields {
+		fields[f.Key] = f.Value
+	}
+}
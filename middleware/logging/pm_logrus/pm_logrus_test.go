@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/pubsub/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/zero-color/pm"
+	pm_logging "github.com/zero-color/pm/middleware/logging"
 )
 
 func TestSubscriptionInterceptor(t *testing.T) {
@@ -85,5 +87,124 @@ func TestSubscriptionInterceptor(t *testing.T) {
 				t.Errorf("log is not expected to be emitted")
 			}
 		})
+
+		t.Run("message fields are logged with redaction", func(t *testing.T) {
+			t.Parallel()
+
+			logger, hook := test.NewNullLogger()
+			interceptor := SubscriptionInterceptor(logger,
+				WithMessageID(),
+				WithAttributes("type", "token"),
+				WithRedactedAttributes("token"),
+				WithPayload(0),
+				WithPayloadMasks("$.email"),
+			)
+			_ = interceptor(testSubInfo, successMessageHandler)(context.Background(), &pubsub.Message{
+				ID:         "message-id",
+				Data:       []byte(`{"email":"a@example.com"}`),
+				Attributes: map[string]string{"type": "created", "token": "secret"},
+			})
+
+			if got := len(hook.Entries); got != 1 {
+				t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+			}
+			for key, want := range map[string]any{
+				"pubsub.message_id":       "message-id",
+				"pubsub.attributes.type":  "created",
+				"pubsub.attributes.token": pm_logging.RedactedValue,
+				"pubsub.payload":          `{"email":"[REDACTED]"}`,
+			} {
+				if got := hook.Entries[0].Data[key]; got != want {
+					t.Errorf("%s field is expected to be set, got: %v, want: %v", key, got, want)
+				}
+			}
+		})
+	})
+}
+
+func TestPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	publisher := (&pubsub.Client{}).Publisher("projects/test-project/topics/test-topic")
+
+	callPublisher := func(f pm.MessagePublisher) {
+		_ = f(context.Background(), publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"})
+	}
+	publish := func(serverID string, err error) pm.MessagePublisher {
+		return func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			return pm.NewPublishResult(serverID, err)
+		}
+	}
+	waitProducer := func(done chan struct{}) Option {
+		return WithMessageProducer(func(ctx context.Context, msg string, err error, duration time.Duration) {
+			DefaultMessageProducer(ctx, msg, err, duration)
+			close(done)
+		})
+	}
+
+	t.Run("emit info log when publishing is successful", func(t *testing.T) {
+		t.Parallel()
+
+		logger, hook := test.NewNullLogger()
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-done
+
+		if got := len(hook.AllEntries()); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		entry := hook.LastEntry()
+		if entry.Level != logrus.InfoLevel {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", entry.Level, logrus.InfoLevel)
+		}
+		wantMessage := "finished publishing message to topic 'test-topic'"
+		if entry.Message != wantMessage {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", entry.Message, wantMessage)
+		}
+		for key, want := range map[string]any{
+			"pubsub.topic_id":     "test-topic",
+			"pubsub.ordering_key": "key",
+			"pubsub.size":         4,
+			"pubsub.message_id":   "server-id",
+		} {
+			if got := entry.Data[key]; got != want {
+				t.Errorf("%s field is expected to be set, got: %v, want: %v", key, got, want)
+			}
+		}
+	})
+
+	t.Run("emit error log when publishing fails", func(t *testing.T) {
+		t.Parallel()
+
+		logger, hook := test.NewNullLogger()
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("", errors.New("error"))))
+		<-done
+
+		if got := len(hook.AllEntries()); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		if got := hook.LastEntry().Level; got != logrus.ErrorLevel {
+			t.Errorf("ERROR log is expected to be emitted, got: %v, want: %v", got, logrus.ErrorLevel)
+		}
+	})
+
+	t.Run("custom options are applied", func(t *testing.T) {
+		t.Parallel()
+
+		logger, hook := test.NewNullLogger()
+		decided := make(chan struct{})
+		interceptor := PublishInterceptor(logger, WithPublishLogDecider(func(topicID string, err error) bool {
+			close(decided)
+			return false
+		}))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-decided
+
+		if len(hook.AllEntries()) != 0 {
+			t.Errorf("log is not expected to be emitted")
+		}
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"cloud.google.com/go/pubsub/v2"
 	"github.com/zero-color/pm"
 )
 
@@ -24,6 +25,38 @@ func TestWithLogDecider(t *testing.T) {
 	}
 }
 
+func TestWithMessageLogDecider(t *testing.T) {
+	t.Parallel()
+
+	opts := options{}
+	isDecided := false
+	customDecider := func(info *pm.SubscriptionInfo, m *pubsub.Message, err error, duration time.Duration) bool {
+		isDecided = true
+		return true
+	}
+	WithMessageLogDecider(customDecider).apply(&opts)
+	opts.shouldLogMessage(nil, nil, nil, 0)
+	if !isDecided {
+		t.Errorf("WithMessageLogDecider() is expected to set custom decider, but it was not set")
+	}
+}
+
+func TestWithPublishLogDecider(t *testing.T) {
+	t.Parallel()
+
+	opts := options{}
+	isDecided := false
+	customDecider := func(topicID string, err error) bool {
+		isDecided = true
+		return true
+	}
+	WithPublishLogDecider(customDecider).apply(&opts)
+	opts.shouldLogPublish("", nil)
+	if !isDecided {
+		t.Errorf("WithPublishLogDecider() is expected to set custom diceider, but it was not set")
+	}
+}
+
 func TestWithMessageProducer(t *testing.T) {
 	t.Parallel()
 
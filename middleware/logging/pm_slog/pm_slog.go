@@ -1,4 +1,4 @@
-// Package pm_slog provides a slog-based logging interceptor for pm subscriptions.
+// Package pm_slog provides slog-based logging interceptors for pm subscriptions and publishing.
 //
 // Example usage:
 //
@@ -11,10 +11,16 @@
 //	logger := slog.Default()
 //	subscriber := pm.NewSubscriber(
 //		client,
-//		pm.WithSubscriptionInterceptors(
+//		pm.WithSubscriptionInterceptor(
 //			pm_slog.SubscriptionInterceptor(logger),
 //		),
 //	)
+//	publisher := pm.NewPublisher(
+//		client,
+//		pm.WithPublishInterceptor(
+//			pm_slog.PublishInterceptor(logger),
+//		),
+//	)
 package pm_slog
 
 import (
@@ -51,9 +57,11 @@ func ToContext(ctx context.Context, logger *slog.Logger) context.Context {
 // SubscriptionInterceptor returns a subscription interceptor that optionally logs the subscription process.
 func SubscriptionInterceptor(logger *slog.Logger, opt ...Option) pm.SubscriptionInterceptor {
 	opts := &options{
-		shouldLog:       pm_logging.DefaultLogDecider,
-		messageProducer: DefaultMessageProducer,
-		timestampFormat: time.RFC3339,
+		shouldLog:        pm_logging.DefaultLogDecider,
+		shouldLogPublish: pm_logging.DefaultPublishLogDecider,
+		shouldLogMessage: pm_logging.DefaultMessageLogDecider,
+		messageProducer:  DefaultMessageProducer,
+		timestampFormat:  time.RFC3339,
 	}
 	for _, o := range opt {
 		o.apply(opts)
@@ -62,15 +70,17 @@ func SubscriptionInterceptor(logger *slog.Logger, opt ...Option) pm.Subscription
 	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
 		return func(ctx context.Context, m *pubsub.Message) error {
 			startTime := time.Now()
-			newCtx := newLoggerForProcess(ctx, logger, info, startTime, opts.timestampFormat)
+			newCtx := newLoggerForProcess(ctx, logger, info, m, startTime, opts)
 
 			err := next(newCtx, m)
 
-			if opts.shouldLog(info, err) {
+			duration := time.Since(startTime)
+
+			if opts.shouldLog(info, err) && opts.shouldLogMessage(info, m, err, duration) {
 				opts.messageProducer(
 					newCtx, fmt.Sprintf("finished processing message '%s'", m.ID),
 					err,
-					time.Since(startTime),
+					duration,
 				)
 			}
 			return err
@@ -78,15 +88,81 @@ func SubscriptionInterceptor(logger *slog.Logger, opt ...Option) pm.Subscription
 	}
 }
 
-func newLoggerForProcess(ctx context.Context, logger *slog.Logger, info *pm.SubscriptionInfo, start time.Time, timestampFormat string) context.Context {
+// PublishInterceptor returns a publish interceptor that optionally logs the publishing process.
+// The log is emitted once the PublishResult is ready, so the caller isn't blocked by logging.
+// WithLogDecider and WithMessageLogDecider are only for the subscription interceptor, so use WithPublishLogDecider
+// to suppress the logs. WithPublishTime and WithDeliveryAttempt are ignored, and the message ID assigned by the server
+// and the ordering key are always logged when they are set.
+func PublishInterceptor(logger *slog.Logger, opt ...Option) pm.PublishInterceptor {
+	opts := &options{
+		shouldLog:        pm_logging.DefaultLogDecider,
+		shouldLogPublish: pm_logging.DefaultPublishLogDecider,
+		shouldLogMessage: pm_logging.DefaultMessageLogDecider,
+		messageProducer:  DefaultMessageProducer,
+		timestampFormat:  time.RFC3339,
+	}
+	for _, o := range opt {
+		o.apply(opts)
+	}
+
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			startTime := time.Now()
+			result := next(ctx, publisher, m)
+
+			go func() {
+				<-result.Ready()
+				duration := time.Since(startTime)
+				serverID, err := result.Get(ctx)
+				if !opts.shouldLogPublish(publisher.ID(), err) {
+					return
+				}
+				newCtx := newLoggerForPublish(ctx, logger, publisher, m, serverID, startTime, opts)
+				opts.messageProducer(
+					newCtx, fmt.Sprintf("finished publishing message to topic '%s'", publisher.ID()),
+					err,
+					duration,
+				)
+			}()
+			return result
+		}
+	}
+}
+
+func newLoggerForProcess(ctx context.Context, logger *slog.Logger, info *pm.SubscriptionInfo, m *pubsub.Message, start time.Time, opts *options) context.Context {
 	args := []any{
-		"pubsub.start_time", start.Format(timestampFormat),
+		"pubsub.start_time", start.Format(opts.timestampFormat),
 	}
 	if d, ok := ctx.Deadline(); ok {
-		args = append(args, "pubsub.deadline", d.Format(timestampFormat))
+		args = append(args, "pubsub.deadline", d.Format(opts.timestampFormat))
 	}
 	args = append(args,
 		"pubsub.subscription_id", info.SubscriptionID,
 	)
+	args = appendMessageFields(args, opts.messageFields.MetadataFields(m, opts.timestampFormat))
+	args = appendMessageFields(args, opts.messageFields.ContentFields(m))
+	return ToContext(ctx, logger.With(args...))
+}
+
+func newLoggerForPublish(ctx context.Context, logger *slog.Logger, publisher *pubsub.Publisher, m *pubsub.Message, serverID string, start time.Time, opts *options) context.Context {
+	args := []any{
+		"pubsub.start_time", start.Format(opts.timestampFormat),
+		"pubsub.topic_id", publisher.ID(),
+	}
+	if m.OrderingKey != "" {
+		args = append(args, "pubsub.ordering_key", m.OrderingKey)
+	}
+	args = append(args, "pubsub.size", len(m.Data))
+	if serverID != "" {
+		args = append(args, "pubsub.message_id", serverID)
+	}
+	args = appendMessageFields(args, opts.messageFields.ContentFields(m))
 	return ToContext(ctx, logger.With(args...))
 }
+
+func appendMessageFields(args []any, messageFields []pm_logging.Field) []any {
+	for _, f := range messageFields {
+		args = append(args, f.Key, f.Value)
+	}
+	return args
+}
@@ -6,9 +6,11 @@ import (
 	"log/slog"
 	"sync"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/pubsub/v2"
 	"github.com/zero-color/pm"
+	pm_logging "github.com/zero-color/pm/middleware/logging"
 )
 
 // testLogEntry represents a captured log entry
@@ -23,6 +25,7 @@ type testHandler struct {
 	mu      sync.Mutex
 	entries *[]testLogEntry
 	level   slog.Level
+	attrs   []slog.Attr
 }
 
 func newTestHandler(level slog.Level) *testHandler {
@@ -42,6 +45,9 @@ func (h *testHandler) Handle(_ context.Context, r slog.Record) error {
 	defer h.mu.Unlock()
 
 	attrs := make(map[string]any)
+	for _, a := range h.attrs {
+		attrs[a.Key] = a.Value.Any()
+	}
 	r.Attrs(func(a slog.Attr) bool {
 		attrs[a.Key] = a.Value.Any()
 		return true
@@ -60,6 +66,7 @@ func (h *testHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	newHandler := &testHandler{
 		entries: h.entries,
 		level:   h.level,
+		attrs:   append(append([]slog.Attr{}, h.attrs...), attrs...),
 	}
 	return newHandler
 }
@@ -162,5 +169,123 @@ func TestSubscriptionInterceptor(t *testing.T) {
 				t.Errorf("log is not expected to be emitted")
 			}
 		})
+
+		t.Run("message fields are logged with redaction", func(t *testing.T) {
+			t.Parallel()
+
+			handler := newTestHandler(slog.LevelInfo)
+			logger := slog.New(handler)
+
+			interceptor := SubscriptionInterceptor(logger,
+				WithMessageID(),
+				WithAttributes("type", "token"),
+				WithRedactedAttributes("token"),
+				WithPayload(0),
+				WithPayloadMasks("$.email"),
+			)
+			_ = interceptor(testSubInfo, successMessageHandler)(context.Background(), &pubsub.Message{
+				ID:         "message-id",
+				Data:       []byte(`{"email":"a@example.com"}`),
+				Attributes: map[string]string{"type": "created", "token": "secret"},
+			})
+
+			if got := handler.Len(); got != 1 {
+				t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+			}
+			attrs := handler.All()[0].attrs
+			for key, want := range map[string]any{
+				"pubsub.message_id":       "message-id",
+				"pubsub.attributes.type":  "created",
+				"pubsub.attributes.token": pm_logging.RedactedValue,
+				"pubsub.payload":          `{"email":"[REDACTED]"}`,
+			} {
+				if got := attrs[key]; got != want {
+					t.Errorf("%s attribute is expected to be set, got: %v, want: %v", key, got, want)
+				}
+			}
+		})
+	})
+}
+
+func TestPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	publisher := (&pubsub.Client{}).Publisher("projects/test-project/topics/test-topic")
+
+	callPublisher := func(f pm.MessagePublisher) {
+		_ = f(context.Background(), publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"})
+	}
+	publish := func(serverID string, err error) pm.MessagePublisher {
+		return func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			return pm.NewPublishResult(serverID, err)
+		}
+	}
+	waitProducer := func(done chan struct{}) Option {
+		return WithMessageProducer(func(ctx context.Context, msg string, err error, duration time.Duration) {
+			DefaultMessageProducer(ctx, msg, err, duration)
+			close(done)
+		})
+	}
+
+	t.Run("emit info log when publishing is successful", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newTestHandler(slog.LevelInfo)
+		logger := slog.New(handler)
+
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-done
+
+		if got := handler.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		entry := handler.All()[0]
+		if got := entry.level; got != slog.LevelInfo {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", got, slog.LevelInfo)
+		}
+		wantMessage := "finished publishing message to topic 'test-topic'"
+		if entry.message != wantMessage {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", entry.message, wantMessage)
+		}
+	})
+
+	t.Run("emit error log when publishing fails", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newTestHandler(slog.LevelError)
+		logger := slog.New(handler)
+
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("", errors.New("error"))))
+		<-done
+
+		if got := handler.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		if got := handler.All()[0].level; got != slog.LevelError {
+			t.Errorf("ERROR log is expected to be emitted, got: %v, want: %v", got, slog.LevelError)
+		}
+	})
+
+	t.Run("custom options are applied", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newTestHandler(slog.LevelDebug)
+		logger := slog.New(handler)
+
+		decided := make(chan struct{})
+		interceptor := PublishInterceptor(logger, WithPublishLogDecider(func(topicID string, err error) bool {
+			close(decided)
+			return false
+		}))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-decided
+
+		if handler.Len() != 0 {
+			t.Errorf("log is not expected to be emitted")
+		}
 	})
 }
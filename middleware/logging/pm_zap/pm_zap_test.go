@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/pubsub/v2"
 	"github.com/zero-color/pm"
+	pm_logging "github.com/zero-color/pm/middleware/logging"
 	"go.uber.org/zap"
 	zapobserver "go.uber.org/zap/zaptest/observer"
 )
@@ -91,5 +93,134 @@ func TestSubscriptionInterceptor(t *testing.T) {
 				t.Errorf("log is not expected to be emitted")
 			}
 		})
+
+		t.Run("message fields are logged with redaction", func(t *testing.T) {
+			t.Parallel()
+
+			core, obs := zapobserver.New(zap.InfoLevel)
+			logger := zap.New(core)
+
+			interceptor := SubscriptionInterceptor(logger,
+				WithMessageID(),
+				WithAttributes("type", "token"),
+				WithRedactedAttributes("token"),
+				WithPayload(0),
+				WithPayloadMasks("$.email"),
+			)
+			_ = interceptor(testSubInfo, successMessageHandler)(context.Background(), &pubsub.Message{
+				ID:         "message-id",
+				Data:       []byte(`{"email":"a@example.com"}`),
+				Attributes: map[string]string{"type": "created", "token": "secret"},
+			})
+
+			if got := obs.Len(); got != 1 {
+				t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+			}
+			fields := obs.All()[0].ContextMap()
+			for key, want := range map[string]any{
+				"pubsub.message_id":       "message-id",
+				"pubsub.attributes.type":  "created",
+				"pubsub.attributes.token": pm_logging.RedactedValue,
+				"pubsub.payload":          `{"email":"[REDACTED]"}`,
+			} {
+				if got := fields[key]; got != want {
+					t.Errorf("%s field is expected to be set, got: %v, want: %v", key, got, want)
+				}
+			}
+		})
+	})
+}
+
+func TestPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	publisher := (&pubsub.Client{}).Publisher("projects/test-project/topics/test-topic")
+
+	callPublisher := func(f pm.MessagePublisher) {
+		_ = f(context.Background(), publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"})
+	}
+	publish := func(serverID string, err error) pm.MessagePublisher {
+		return func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			return pm.NewPublishResult(serverID, err)
+		}
+	}
+	waitProducer := func(done chan struct{}) Option {
+		return WithMessageProducer(func(ctx context.Context, msg string, err error, duration time.Duration) {
+			DefaultMessageProducer(ctx, msg, err, duration)
+			close(done)
+		})
+	}
+
+	t.Run("emit info log when publishing is successful", func(t *testing.T) {
+		t.Parallel()
+
+		core, obs := zapobserver.New(zap.InfoLevel)
+		logger := zap.New(core)
+
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-done
+
+		if got := obs.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		entry := obs.All()[0]
+		if got := entry.Level; got != zap.InfoLevel {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", got, zap.InfoLevel)
+		}
+		wantMessage := "finished publishing message to topic 'test-topic'"
+		if entry.Message != wantMessage {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", entry.Message, wantMessage)
+		}
+		fields := entry.ContextMap()
+		for key, want := range map[string]any{
+			"pubsub.topic_id":     "test-topic",
+			"pubsub.ordering_key": "key",
+			"pubsub.size":         int64(4),
+			"pubsub.message_id":   "server-id",
+		} {
+			if got := fields[key]; got != want {
+				t.Errorf("%s field is expected to be set, got: %v, want: %v", key, got, want)
+			}
+		}
+	})
+
+	t.Run("emit error log when publishing fails", func(t *testing.T) {
+		t.Parallel()
+
+		core, obs := zapobserver.New(zap.ErrorLevel)
+		logger := zap.New(core)
+
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("", errors.New("error"))))
+		<-done
+
+		if got := obs.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		if got := obs.All()[0].Level; got != zap.ErrorLevel {
+			t.Errorf("ERROR log is expected to be emitted, got: %v, want: %v", got, zap.ErrorLevel)
+		}
+	})
+
+	t.Run("custom options are applied", func(t *testing.T) {
+		t.Parallel()
+
+		core, obs := zapobserver.New(zap.DebugLevel)
+		logger := zap.New(core)
+
+		decided := make(chan struct{})
+		interceptor := PublishInterceptor(logger, WithPublishLogDecider(func(topicID string, err error) bool {
+			close(decided)
+			return false
+		}))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-decided
+
+		if obs.Len() != 0 {
+			t.Errorf("log is not expected to be emitted")
+		}
 	})
 }
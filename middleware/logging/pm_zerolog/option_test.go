@@ -0,0 +1,74 @@
+package pm_zerolog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+func TestWithLogDecider(t *testing.T) {
+	t.Parallel()
+
+	opts := options{}
+	isDecided := false
+	customDecider := func(info *pm.SubscriptionInfo, err error) bool {
+		isDecided = true
+		return true
+	}
+	WithLogDecider(customDecider).apply(&opts)
+	opts.shouldLog(nil, nil)
+	if !isDecided {
+		t.Errorf("WithLogDecider() is expected to set custom decider, but it was not set")
+	}
+}
+
+func TestWithMessageLogDecider(t *testing.T) {
+	t.Parallel()
+
+	opts := options{}
+	isDecided := false
+	customDecider := func(info *pm.SubscriptionInfo, m *pubsub.Message, err error, duration time.Duration) bool {
+		isDecided = true
+		return true
+	}
+	WithMessageLogDecider(customDecider).apply(&opts)
+	opts.shouldLogMessage(nil, nil, nil, 0)
+	if !isDecided {
+		t.Errorf("WithMessageLogDecider() is expected to set custom decider, but it was not set")
+	}
+}
+
+func TestWithPublishLogDecider(t *testing.T) {
+	t.Parallel()
+
+	opts := options{}
+	isDecided := false
+	customDecider := func(topicID string, err error) bool {
+		isDecided = true
+		return true
+	}
+	WithPublishLogDecider(customDecider).apply(&opts)
+	opts.shouldLogPublish("", nil)
+	if !isDecided {
+		t.Errorf("WithPublishLogDecider() is expected to set custom diceider, but it was not set")
+	}
+}
+
+func TestWithMessageProducer(t *testing.T) {
+	t.Parallel()
+
+	opts := options{}
+
+	isProduced := false
+	customMessageProducer := func(ctx context.Context, msg string, err error, duration time.Duration) {
+		isProduced = true
+	}
+	WithMessageProducer(customMessageProducer).apply(&opts)
+	opts.messageProducer(nil, "", nil, 0)
+	if !isProduced {
+		t.Errorf("WithMessageProducer() is expected to set custom message producer, but it was not set")
+	}
+}
@@ -0,0 +1,156 @@
+// Package pm_zerolog provides zerolog-based logging interceptors for pm subscriptions and publishing.
+//
+// Example usage:
+//
+//	import (
+//		"os"
+//		"github.com/rs/zerolog"
+//		"github.com/zero-color/pm"
+//		"github.com/zero-color/pm/middleware/logging/pm_zerolog"
+//	)
+//
+//	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+//	subscriber := pm.NewSubscriber(
+//		client,
+//		pm.WithSubscriptionInterceptor(
+//			pm_zerolog.SubscriptionInterceptor(logger),
+//		),
+//	)
+//	publisher := pm.NewPublisher(
+//		client,
+//		pm.WithPublishInterceptor(
+//			pm_zerolog.PublishInterceptor(logger),
+//		),
+//	)
+package pm_zerolog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/rs/zerolog"
+	"github.com/zero-color/pm"
+	pm_logging "github.com/zero-color/pm/middleware/logging"
+)
+
+// Extract returns the zerolog.Logger from the context.
+// If no logger is found, it returns zerolog.DefaultContextLogger or a disabled logger.
+// It's equivalent to zerolog.Ctx, so loggers injected with zerolog.Logger.WithContext are also extracted.
+func Extract(ctx context.Context) *zerolog.Logger {
+	return zerolog.Ctx(ctx)
+}
+
+// ToContext adds the zerolog.Logger to the context.
+func ToContext(ctx context.Context, logger zerolog.Logger) context.Context {
+	return logger.WithContext(ctx)
+}
+
+// SubscriptionInterceptor returns a subscription interceptor that optionally logs the subscription process.
+func SubscriptionInterceptor(logger zerolog.Logger, opt ...Option) pm.SubscriptionInterceptor {
+	opts := &options{
+		shouldLog:        pm_logging.DefaultLogDecider,
+		shouldLogPublish: pm_logging.DefaultPublishLogDecider,
+		shouldLogMessage: pm_logging.DefaultMessageLogDecider,
+		messageProducer:  DefaultMessageProducer,
+		timestampFormat:  time.RFC3339,
+	}
+	for _, o := range opt {
+		o.apply(opts)
+	}
+
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		return func(ctx context.Context, m *pubsub.Message) error {
+			startTime := time.Now()
+			newCtx := newLoggerForProcess(ctx, logger, info, m, startTime, opts)
+
+			err := next(newCtx, m)
+
+			duration := time.Since(startTime)
+
+			if opts.shouldLog(info, err) && opts.shouldLogMessage(info, m, err, duration) {
+				opts.messageProducer(
+					newCtx, fmt.Sprintf("finished processing message '%s'", m.ID),
+					err,
+					duration,
+				)
+			}
+			return err
+		}
+	}
+}
+
+// PublishInterceptor returns a publish interceptor that optionally logs the publishing process.
+// The log is emitted once the PublishResult is ready, so the caller isn't blocked by logging.
+// WithLogDecider and WithMessageLogDecider are only for the subscription interceptor, so use WithPublishLogDecider
+// to suppress the logs. WithPublishTime and WithDeliveryAttempt are ignored, and the message ID assigned by the server
+// and the ordering key are always logged when they are set.
+func PublishInterceptor(logger zerolog.Logger, opt ...Option) pm.PublishInterceptor {
+	opts := &options{
+		shouldLog:        pm_logging.DefaultLogDecider,
+		shouldLogPublish: pm_logging.DefaultPublishLogDecider,
+		shouldLogMessage: pm_logging.DefaultMessageLogDecider,
+		messageProducer:  DefaultMessageProducer,
+		timestampFormat:  time.RFC3339,
+	}
+	for _, o := range opt {
+		o.apply(opts)
+	}
+
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			startTime := time.Now()
+			result := next(ctx, publisher, m)
+
+			go func() {
+				<-result.Ready()
+				duration := time.Since(startTime)
+				serverID, err := result.Get(ctx)
+				if !opts.shouldLogPublish(publisher.ID(), err) {
+					return
+				}
+				newCtx := newLoggerForPublish(ctx, logger, publisher, m, serverID, startTime, opts)
+				opts.messageProducer(
+					newCtx, fmt.Sprintf("finished publishing message to topic '%s'", publisher.ID()),
+					err,
+					duration,
+				)
+			}()
+			return result
+		}
+	}
+}
+
+func newLoggerForProcess(ctx context.Context, logger zerolog.Logger, info *pm.SubscriptionInfo, m *pubsub.Message, start time.Time, opts *options) context.Context {
+	c := logger.With().Str("pubsub.start_time", start.Format(opts.timestampFormat))
+	if d, ok := ctx.Deadline(); ok {
+		c = c.Str("pubsub.deadline", d.Format(opts.timestampFormat))
+	}
+	c = c.Str("pubsub.subscription_id", info.SubscriptionID)
+	c = withMessageFields(c, opts.messageFields.MetadataFields(m, opts.timestampFormat))
+	c = withMessageFields(c, opts.messageFields.ContentFields(m))
+	return ToContext(ctx, c.Logger())
+}
+
+func newLoggerForPublish(ctx context.Context, logger zerolog.Logger, publisher *pubsub.Publisher, m *pubsub.Message, serverID string, start time.Time, opts *options) context.Context {
+	c := logger.With().
+		Str("pubsub.start_time", start.Format(opts.timestampFormat)).
+		Str("pubsub.topic_id", publisher.ID())
+	if m.OrderingKey != "" {
+		c = c.Str("pubsub.ordering_key", m.OrderingKey)
+	}
+	c = c.Int("pubsub.size", len(m.Data))
+	if serverID != "" {
+		c = c.Str("pubsub.message_id", serverID)
+	}
+	c = withMessageFields(c, opts.messageFields.ContentFields(m))
+	return ToContext(ctx, c.Logger())
+}
+
+func withMessageFields(c zerolog.Context, messageFields []pm_logging.Field) zerolog.Context {
+	for _, f := range messageFields {
+		c = c.Interface(f.Key, f.Value)
+	}
+	return c
+}
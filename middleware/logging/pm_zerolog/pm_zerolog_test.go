@@ -0,0 +1,266 @@
+package pm_zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/rs/zerolog"
+	"github.com/zero-color/pm"
+	pm_logging "github.com/zero-color/pm/middleware/logging"
+)
+
+// testWriter captures JSON log entries written by zerolog for testing
+type testWriter struct {
+	mu      sync.Mutex
+	entries []map[string]any
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, line := range bytes.Split(bytes.TrimSpace(p), []byte("\n")) {
+		entry := map[string]any{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return 0, err
+		}
+		w.entries = append(w.entries, entry)
+	}
+	return len(p), nil
+}
+
+func (w *testWriter) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.entries)
+}
+
+func (w *testWriter) All() []map[string]any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	result := make([]map[string]any, len(w.entries))
+	copy(result, w.entries)
+	return result
+}
+
+func newTestLogger(level zerolog.Level) (zerolog.Logger, *testWriter) {
+	w := &testWriter{}
+	return zerolog.New(w).Level(level), w
+}
+
+func TestToContext(t *testing.T) {
+	t.Parallel()
+
+	logger, w := newTestLogger(zerolog.InfoLevel)
+	ctx := ToContext(context.Background(), logger.With().Str("key", "value").Logger())
+	Extract(ctx).Info().Msg("test")
+
+	if got := w.Len(); got != 1 {
+		t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+	}
+	if got := w.All()[0]["key"]; got != "value" {
+		t.Errorf("Extract() is expected to return the logger set by ToContext(), got field: %v, want: %v", got, "value")
+	}
+}
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	testSubInfo := &pm.SubscriptionInfo{
+		SubscriptionID: "test-sub",
+	}
+
+	successMessageHandler := func(ctx context.Context, m *pubsub.Message) error {
+		return nil
+	}
+	failureMessageHandler := func(ctx context.Context, m *pubsub.Message) error {
+		return errors.New("error")
+	}
+
+	callHandler := func(f pm.MessageHandler) {
+		_ = f(context.Background(), &pubsub.Message{ID: "message-id"})
+	}
+
+	t.Run("with default options", func(t *testing.T) {
+		t.Run("emit info log when processing is successful", func(t *testing.T) {
+			t.Parallel()
+
+			logger, w := newTestLogger(zerolog.InfoLevel)
+			interceptor := SubscriptionInterceptor(logger)
+			callHandler(interceptor(testSubInfo, successMessageHandler))
+
+			if got := w.Len(); got != 1 {
+				t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+			}
+			entry := w.All()[0]
+			if got := entry[zerolog.LevelFieldName]; got != zerolog.InfoLevel.String() {
+				t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", got, zerolog.InfoLevel)
+			}
+			wantMessage := "finished processing message 'message-id'"
+			if got := entry[zerolog.MessageFieldName]; got != wantMessage {
+				t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", got, wantMessage)
+			}
+			if got := entry["pubsub.subscription_id"]; got != "test-sub" {
+				t.Errorf("pubsub.subscription_id field is expected to be set, got: %v, want: %v", got, "test-sub")
+			}
+		})
+
+		t.Run("Emit error log when processing fails", func(t *testing.T) {
+			t.Parallel()
+
+			logger, w := newTestLogger(zerolog.ErrorLevel)
+			interceptor := SubscriptionInterceptor(logger)
+			callHandler(interceptor(testSubInfo, failureMessageHandler))
+
+			if got := w.Len(); got != 1 {
+				t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+			}
+			entry := w.All()[0]
+			if got := entry[zerolog.LevelFieldName]; got != zerolog.ErrorLevel.String() {
+				t.Errorf("ERROR log is expected to be emitted, got: %v, want: %v", got, zerolog.ErrorLevel)
+			}
+			if got := entry[zerolog.ErrorFieldName]; got != "error" {
+				t.Errorf("error field is expected to be set, got: %v, want: %v", got, "error")
+			}
+		})
+	})
+
+	t.Run("with custom options", func(t *testing.T) {
+		t.Run("custom options are applied", func(t *testing.T) {
+			t.Parallel()
+
+			logger, w := newTestLogger(zerolog.DebugLevel)
+			interceptor := SubscriptionInterceptor(logger, WithLogDecider(func(info *pm.SubscriptionInfo, err error) bool {
+				return false
+			}))
+			callHandler(interceptor(testSubInfo, successMessageHandler))
+
+			if w.Len() != 0 {
+				t.Errorf("log is not expected to be emitted")
+			}
+		})
+
+		t.Run("message fields are logged with redaction", func(t *testing.T) {
+			t.Parallel()
+
+			logger, w := newTestLogger(zerolog.InfoLevel)
+			interceptor := SubscriptionInterceptor(logger,
+				WithMessageID(),
+				WithAttributes("type", "token"),
+				WithRedactedAttributes("token"),
+				WithPayload(0),
+				WithPayloadMasks("$.email"),
+			)
+			_ = interceptor(testSubInfo, successMessageHandler)(context.Background(), &pubsub.Message{
+				ID:         "message-id",
+				Data:       []byte(`{"email":"a@example.com"}`),
+				Attributes: map[string]string{"type": "created", "token": "secret"},
+			})
+
+			if got := w.Len(); got != 1 {
+				t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+			}
+			entry := w.All()[0]
+			for key, want := range map[string]any{
+				"pubsub.message_id":       "message-id",
+				"pubsub.attributes.type":  "created",
+				"pubsub.attributes.token": pm_logging.RedactedValue,
+				"pubsub.payload":          `{"email":"[REDACTED]"}`,
+			} {
+				if got := entry[key]; got != want {
+					t.Errorf("%s field is expected to be set, got: %v, want: %v", key, got, want)
+				}
+			}
+		})
+	})
+}
+
+func TestPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	publisher := (&pubsub.Client{}).Publisher("projects/test-project/topics/test-topic")
+
+	callPublisher := func(f pm.MessagePublisher) {
+		_ = f(context.Background(), publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"})
+	}
+	publish := func(serverID string, err error) pm.MessagePublisher {
+		return func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			return pm.NewPublishResult(serverID, err)
+		}
+	}
+	waitProducer := func(done chan struct{}) Option {
+		return WithMessageProducer(func(ctx context.Context, msg string, err error, duration time.Duration) {
+			DefaultMessageProducer(ctx, msg, err, duration)
+			close(done)
+		})
+	}
+
+	t.Run("emit info log when publishing is successful", func(t *testing.T) {
+		t.Parallel()
+
+		logger, w := newTestLogger(zerolog.InfoLevel)
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-done
+
+		if got := w.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		entry := w.All()[0]
+		wantMessage := "finished publishing message to topic 'test-topic'"
+		if got := entry[zerolog.MessageFieldName]; got != wantMessage {
+			t.Errorf("INFO log is expected to be emitted, got: %v, want: %v", got, wantMessage)
+		}
+		for key, want := range map[string]any{
+			"pubsub.topic_id":     "test-topic",
+			"pubsub.ordering_key": "key",
+			"pubsub.size":         float64(4),
+			"pubsub.message_id":   "server-id",
+		} {
+			if got := entry[key]; got != want {
+				t.Errorf("%s field is expected to be set, got: %v, want: %v", key, got, want)
+			}
+		}
+	})
+
+	t.Run("emit error log when publishing fails", func(t *testing.T) {
+		t.Parallel()
+
+		logger, w := newTestLogger(zerolog.ErrorLevel)
+		done := make(chan struct{})
+		interceptor := PublishInterceptor(logger, waitProducer(done))
+		callPublisher(interceptor(publish("", errors.New("error"))))
+		<-done
+
+		if got := w.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		if got := w.All()[0][zerolog.LevelFieldName]; got != zerolog.ErrorLevel.String() {
+			t.Errorf("ERROR log is expected to be emitted, got: %v, want: %v", got, zerolog.ErrorLevel)
+		}
+	})
+
+	t.Run("custom options are applied", func(t *testing.T) {
+		t.Parallel()
+
+		logger, w := newTestLogger(zerolog.DebugLevel)
+		decided := make(chan struct{})
+		interceptor := PublishInterceptor(logger, WithPublishLogDecider(func(topicID string, err error) bool {
+			close(decided)
+			return false
+		}))
+		callPublisher(interceptor(publish("server-id", nil)))
+		<-decided
+
+		if w.Len() != 0 {
+			t.Errorf("log is not expected to be emitted")
+		}
+	})
+}
@@ -11,7 +11,7 @@ import (
 // This interceptor doesn't overwrite if already the same key's attribute is set.
 func PublishInterceptor(attrs map[string]string) pm.PublishInterceptor {
 	return func(next pm.MessagePublisher) pm.MessagePublisher {
-		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) *pubsub.PublishResult {
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
 			for k, v := range attrs {
 				if _, ok := m.Attributes[k]; !ok {
 					m.Attributes[k] = v
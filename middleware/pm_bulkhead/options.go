@@ -0,0 +1,57 @@
+package pm_bulkhead
+
+import (
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// KeyFunc returns the key which the concurrency limit is applied to.
+type KeyFunc func(info *pm.SubscriptionInfo, m *pubsub.Message) string
+
+// SubscriptionKey is the KeyFunc which applies the concurrency limit per subscription.
+func SubscriptionKey(info *pm.SubscriptionInfo, _ *pubsub.Message) string {
+	return info.SubscriptionID
+}
+
+// AttributeKey returns the KeyFunc which applies the concurrency limit per value of the given attribute such as a tenant ID.
+// When the attribute is not set, the concurrency limit is applied per subscription.
+func AttributeKey(attribute string) KeyFunc {
+	return func(info *pm.SubscriptionInfo, m *pubsub.Message) string {
+		if v, ok := m.Attributes[attribute]; ok {
+			return v
+		}
+		return info.SubscriptionID
+	}
+}
+
+type options struct {
+	keyFunc   KeyFunc
+	keyLimits map[string]int
+	maxWait   time.Duration
+}
+
+type Option func(*options)
+
+// WithKeyFunc customizes the function for deciding the key which the concurrency limit is applied to.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = f
+	}
+}
+
+// WithKeyLimits overrides the concurrency limit for the given keys.
+func WithKeyLimits(limits map[string]int) Option {
+	return func(o *options) {
+		o.keyLimits = limits
+	}
+}
+
+// WithMaxWait customizes how long the message waits for a free slot.
+// When no slot gets free within the duration, the message is nacked.
+func WithMaxWait(d time.Duration) Option {
+	return func(o *options) {
+		o.maxWait = d
+	}
+}
@@ -0,0 +1,151 @@
+package pm_bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// ErrBulkheadFull is returned when no slot gets free within the max wait.
+var ErrBulkheadFull = errors.New("pm_bulkhead: concurrency limit exceeded")
+
+// Stats contains the concurrency of a key.
+type Stats struct {
+	// InFlight is the number of running handlers.
+	InFlight int
+	// Waiting is the number of messages waiting for a free slot.
+	Waiting int
+}
+
+// Bulkhead caps how many handlers run at once per key.
+type Bulkhead struct {
+	mu      sync.Mutex
+	opts    *options
+	limit   int
+	entries map[string]*entry
+}
+
+type entry struct {
+	slots chan struct{}
+	// refs is the number of in-flight and waiting messages.
+	refs int
+}
+
+// New initializes Bulkhead which allows up to limit handlers at once per key.
+// By default, the limit is applied per subscription, and the message waits up to 1 second.
+// It panics when the limit or a key limit isn't positive.
+func New(limit int, opt ...Option) *Bulkhead {
+	opts := options{
+		keyFunc: SubscriptionKey,
+		maxWait: 1 * time.Second,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if limit <= 0 {
+		panic(fmt.Sprintf("pm_bulkhead: limit must be positive, got: %d", limit))
+	}
+	for key, l := range opts.keyLimits {
+		if l <= 0 {
+			panic(fmt.Sprintf("pm_bulkhead: limit of key '%s' must be positive, got: %d", key, l))
+		}
+	}
+	return &Bulkhead{
+		opts:    &opts,
+		limit:   limit,
+		entries: map[string]*entry{},
+	}
+}
+
+// Stats returns the concurrency of the keys which have in-flight or waiting messages.
+func (b *Bulkhead) Stats() map[string]Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := make(map[string]Stats, len(b.entries))
+	for key, e := range b.entries {
+		inFlight := len(e.slots)
+		stats[key] = Stats{InFlight: inFlight, Waiting: e.refs - inFlight}
+	}
+	return stats
+}
+
+func (b *Bulkhead) acquire(ctx context.Context, key string) (release func(), err error) {
+	b.mu.Lock()
+	e, ok := b.entries[key]
+	if !ok {
+		limit := b.limit
+		if l, ok := b.opts.keyLimits[key]; ok {
+			limit = l
+		}
+		e = &entry{slots: make(chan struct{}, limit)}
+		b.entries[key] = e
+	}
+	e.refs++
+	b.mu.Unlock()
+
+	unref := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		e.refs--
+		if e.refs == 0 {
+			delete(b.entries, key)
+		}
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+		return func() {
+			<-e.slots
+			unref()
+		}, nil
+	default:
+	}
+
+	timer := time.NewTimer(b.opts.maxWait)
+	defer timer.Stop()
+	select {
+	case e.slots <- struct{}{}:
+		return func() {
+			<-e.slots
+			unref()
+		}, nil
+	case <-timer.C:
+		unref()
+		return nil, ErrBulkheadFull
+	case <-ctx.Done():
+		unref()
+		return nil, ctx.Err()
+	}
+}
+
+// SubscriptionInterceptor caps how many handlers run at once per key with the given Bulkhead,
+// so that one noisy key can't use every goroutine.
+// Excess messages wait for a free slot up to the max wait, and then are nacked with ErrBulkheadFull.
+//
+// // subscriber
+// bulkhead := pm_bulkhead.New(5, pm_bulkhead.WithKeyFunc(pm_bulkhead.AttributeKey("tenant_id")))
+// pubsubSubscriber := pm.NewSubscriber(
+//
+//		pubsubClient,
+//		pm.WithSubscriptionInterceptor(
+//			pm_bulkhead.SubscriptionInterceptor(bulkhead),
+//		),
+//	)
+func SubscriptionInterceptor(bulkhead *Bulkhead) pm.SubscriptionInterceptor {
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		return func(ctx context.Context, m *pubsub.Message) error {
+			release, err := bulkhead.acquire(ctx, bulkhead.opts.keyFunc(info, m))
+			if err != nil {
+				m.Nack()
+				return err
+			}
+			defer release()
+			return next(ctx, m)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package pm_bulkhead
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	testSubInfo := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+	tenantMessage := func(tenantID string) *pubsub.Message {
+		return &pubsub.Message{Attributes: map[string]string{"tenant_id": tenantID}}
+	}
+
+	t.Run("caps the concurrency per key and exports the stats", func(t *testing.T) {
+		t.Parallel()
+
+		bulkhead := New(1, WithKeyFunc(AttributeKey("tenant_id")), WithMaxWait(10*time.Millisecond))
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		h := SubscriptionInterceptor(bulkhead)(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = h(context.Background(), tenantMessage("a"))
+		}()
+		<-started
+
+		if got := bulkhead.Stats()["a"]; got.InFlight != 1 {
+			t.Errorf("Stats() of key 'a' got: %+v, want 1 in flight", got)
+		}
+		if err := h(context.Background(), tenantMessage("a")); !errors.Is(err, ErrBulkheadFull) {
+			t.Errorf("SubscriptionInterceptor is expected to return ErrBulkheadFull, but got err: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h(context.Background(), tenantMessage("b")); err != nil {
+				t.Errorf("SubscriptionInterceptor is expected to return nil for another key, but got err: %v", err)
+			}
+		}()
+		<-started
+
+		close(finish)
+		wg.Wait()
+		if got := bulkhead.Stats(); len(got) != 0 {
+			t.Errorf("Stats() is expected to be empty after all handlers finish, got: %v", got)
+		}
+	})
+
+	t.Run("waiting message is handled when a slot gets free", func(t *testing.T) {
+		t.Parallel()
+
+		bulkhead := New(1, WithMaxWait(1*time.Second))
+		h := SubscriptionInterceptor(bulkhead)(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := h(context.Background(), &pubsub.Message{}); err != nil {
+					t.Errorf("SubscriptionInterceptor is expected to return nil, but got err: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("applies the key limit", func(t *testing.T) {
+		t.Parallel()
+
+		bulkhead := New(1, WithKeyFunc(AttributeKey("tenant_id")), WithKeyLimits(map[string]int{"a": 2}), WithMaxWait(10*time.Millisecond))
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		h := SubscriptionInterceptor(bulkhead)(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := h(context.Background(), tenantMessage("a")); err != nil {
+					t.Errorf("SubscriptionInterceptor is expected to return nil, but got err: %v", err)
+				}
+			}()
+			<-started
+		}
+		if got := bulkhead.Stats()["a"]; got.InFlight != 2 {
+			t.Errorf("Stats() of key 'a' got: %+v, want 2 in flight", got)
+		}
+		close(finish)
+		wg.Wait()
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		limit int
+		opt   []Option
+	}{
+		{name: "zero limit", limit: 0},
+		{name: "negative limit", limit: -1},
+		{name: "zero key limit", limit: 1, opt: []Option{WithKeyLimits(map[string]int{"key": 0})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("New is expected to panic")
+				}
+			}()
+			New(tt.limit, tt.opt...)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package pm_circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State represents the state of the circuit breaker.
+type State int
+
+const (
+	// StateClosed lets all messages through to the handler.
+	StateClosed State = iota
+	// StateOpen short-circuits all messages.
+	StateOpen
+	// StateHalfOpen lets a limited number of trial messages through to the handler.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const numBuckets = 10
+
+type bucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+type transition struct {
+	from, to State
+}
+
+// breaker tracks the failure rate in a sliding window which consists of numBuckets buckets.
+type breaker struct {
+	mu                sync.Mutex
+	opts              *options
+	state             State
+	buckets           [numBuckets]bucket
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+func newBreaker(opts *options) *breaker {
+	return &breaker{opts: opts}
+}
+
+// allow reports whether the message can be handled, and whether it's a trial in the half-open state.
+func (b *breaker) allow() (ok bool, trial bool, transitions []transition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.opts.now().Sub(b.openedAt) < b.opts.cooldown {
+			return false, false, nil
+		}
+		transitions = append(transitions, b.setState(StateHalfOpen))
+	}
+	if b.state == StateHalfOpen {
+		if b.halfOpenInFlight+b.halfOpenSuccesses >= b.opts.halfOpenMaxRequests {
+			return false, false, transitions
+		}
+		b.halfOpenInFlight++
+		return true, true, transitions
+	}
+	return true, false, transitions
+}
+
+// done records the result of the handled message.
+func (b *breaker) done(trial bool, failed bool) []transition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.opts.now()
+	if trial {
+		if b.state != StateHalfOpen {
+			return nil
+		}
+		b.halfOpenInFlight--
+		if failed {
+			return []transition{b.setState(StateOpen)}
+		}
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.opts.halfOpenMaxRequests {
+			return []transition{b.setState(StateClosed)}
+		}
+		return nil
+	}
+	if b.state != StateClosed {
+		return nil
+	}
+
+	bucketSize := b.opts.window / numBuckets
+	current := &b.buckets[now.UnixNano()/int64(bucketSize)%numBuckets]
+	if start := now.Truncate(bucketSize); !current.start.Equal(start) {
+		*current = bucket{start: start}
+	}
+	if failed {
+		current.failures++
+	} else {
+		current.successes++
+	}
+
+	var successes, failures int
+	for _, bk := range b.buckets {
+		if now.Sub(bk.start) < b.opts.window {
+			successes += bk.successes
+			failures += bk.failures
+		}
+	}
+	total := successes + failures
+	if total >= b.opts.minRequests && float64(failures)/float64(total) >= b.opts.failureRateThreshold {
+		return []transition{b.setState(StateOpen)}
+	}
+	return nil
+}
+
+// waitDuration returns how long to wait before the message can be tried again.
+func (b *breaker) waitDuration() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if d := b.opts.cooldown - b.opts.now().Sub(b.openedAt); d > 0 {
+			return d
+		}
+	}
+	// trial messages are in flight in the half-open state
+	return b.opts.cooldown / numBuckets
+}
+
+func (b *breaker) setState(to State) transition {
+	t := transition{from: b.state, to: to}
+	b.state = to
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	switch to {
+	case StateOpen:
+		b.openedAt = b.opts.now()
+	case StateClosed:
+		b.buckets = [numBuckets]bucket{}
+	}
+	return t
+}
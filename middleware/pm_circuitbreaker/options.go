@@ -0,0 +1,108 @@
+package pm_circuitbreaker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zero-color/pm"
+)
+
+// StateChangeFunc is a function called when the state of the circuit breaker changes.
+type StateChangeFunc func(info *pm.SubscriptionInfo, from, to State)
+
+// FailureDecider decides whether the error returned by the handler is counted as a failure.
+type FailureDecider func(err error) bool
+
+type options struct {
+	window               time.Duration
+	failureRateThreshold float64
+	minRequests          int
+	cooldown             time.Duration
+	halfOpenMaxRequests  int
+	pauseWhileOpen       bool
+	stateChangeFunc      StateChangeFunc
+	isFailure            FailureDecider
+	now                  func() time.Time
+}
+
+type Option func(*options)
+
+func (o *options) validate() {
+	switch {
+	case o.window < numBuckets:
+		// the window is divided into numBuckets buckets of at least 1ns.
+		panic(fmt.Sprintf("pm_circuitbreaker: window must be at least %v, got: %v", time.Duration(numBuckets), o.window))
+	case o.failureRateThreshold <= 0 || o.failureRateThreshold > 1:
+		panic(fmt.Sprintf("pm_circuitbreaker: failure rate threshold must be greater than 0 and at most 1, got: %v", o.failureRateThreshold))
+	case o.minRequests < 0:
+		panic(fmt.Sprintf("pm_circuitbreaker: min requests must not be negative, got: %d", o.minRequests))
+	case o.cooldown < 0:
+		panic(fmt.Sprintf("pm_circuitbreaker: cooldown must not be negative, got: %v", o.cooldown))
+	case o.halfOpenMaxRequests <= 0:
+		panic(fmt.Sprintf("pm_circuitbreaker: half-open max requests must be positive, got: %d", o.halfOpenMaxRequests))
+	}
+}
+
+func defaultFailureDecider(err error) bool {
+	return err != nil
+}
+
+// WithWindow customizes the sliding window in which the failure rate is calculated.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithFailureRateThreshold customizes the failure rate (0 to 1) at which the circuit breaker opens.
+func WithFailureRateThreshold(rate float64) Option {
+	return func(o *options) {
+		o.failureRateThreshold = rate
+	}
+}
+
+// WithMinRequests customizes the minimum number of requests in the window before the circuit breaker can open.
+func WithMinRequests(n int) Option {
+	return func(o *options) {
+		o.minRequests = n
+	}
+}
+
+// WithCooldown customizes how long the circuit breaker stays open before it becomes half-open.
+func WithCooldown(d time.Duration) Option {
+	return func(o *options) {
+		o.cooldown = d
+	}
+}
+
+// WithHalfOpenMaxRequests customizes the number of trial requests allowed while half-open.
+// The circuit breaker closes when all of them succeed.
+func WithHalfOpenMaxRequests(n int) Option {
+	return func(o *options) {
+		o.halfOpenMaxRequests = n
+	}
+}
+
+// WithPauseWhileOpen makes the interceptor hold messages until the circuit breaker becomes half-open
+// instead of nacking them immediately.
+// Held messages occupy the flow control of the subscription, so pulling stops once
+// ReceiveSettings.MaxOutstandingMessages is reached.
+func WithPauseWhileOpen() Option {
+	return func(o *options) {
+		o.pauseWhileOpen = true
+	}
+}
+
+// WithStateChangeFunc sets the function called when the state of the circuit breaker changes.
+func WithStateChangeFunc(f StateChangeFunc) Option {
+	return func(o *options) {
+		o.stateChangeFunc = f
+	}
+}
+
+// WithFailureDecider customizes the function for deciding if the error is counted as a failure.
+func WithFailureDecider(f FailureDecider) Option {
+	return func(o *options) {
+		o.isFailure = f
+	}
+}
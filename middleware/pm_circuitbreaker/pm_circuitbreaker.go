@@ -0,0 +1,77 @@
+package pm_circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// ErrOpen is returned when the message is short-circuited by the open circuit breaker.
+var ErrOpen = errors.New("pm_circuitbreaker: circuit breaker is open")
+
+// SubscriptionInterceptor tracks the handler failure rate per subscription and opens the circuit breaker
+// when it exceeds the threshold.
+// While the circuit breaker is open, messages are nacked without invoking the handler and ErrOpen is returned.
+// After the cooldown, it becomes half-open and lets trial messages through to decide whether to close again.
+// It panics when the options are invalid, such as a window shorter than 10ns or non-positive half-open max requests.
+func SubscriptionInterceptor(opt ...Option) pm.SubscriptionInterceptor {
+	opts := options{
+		window:               10 * time.Second,
+		failureRateThreshold: 0.5,
+		minRequests:          10,
+		cooldown:             30 * time.Second,
+		halfOpenMaxRequests:  1,
+		isFailure:            defaultFailureDecider,
+		now:                  time.Now,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	opts.validate()
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		b := newBreaker(&opts)
+		notify := func(transitions []transition) {
+			if opts.stateChangeFunc == nil {
+				return
+			}
+			for _, t := range transitions {
+				opts.stateChangeFunc(info, t.from, t.to)
+			}
+		}
+		return func(ctx context.Context, m *pubsub.Message) error {
+			ok, trial, transitions := b.allow()
+			notify(transitions)
+			for !ok && opts.pauseWhileOpen {
+				timer := time.NewTimer(b.waitDuration())
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					m.Nack()
+					return ctx.Err()
+				case <-timer.C:
+				}
+				ok, trial, transitions = b.allow()
+				notify(transitions)
+			}
+			if !ok {
+				m.Nack()
+				return ErrOpen
+			}
+
+			// a panicking handler counts as a failure, so that a half-open trial is always finished.
+			panicked := true
+			defer func() {
+				if panicked {
+					notify(b.done(trial, true))
+				}
+			}()
+			err := next(ctx, m)
+			panicked = false
+			notify(b.done(trial, opts.isFailure(err)))
+			return err
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package pm_circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func withClock(c *fakeClock) Option {
+	return func(o *options) {
+		o.now = c.Now
+	}
+}
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	testSubInfo := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+
+	t.Run("opens when the failure rate exceeds the threshold and closes after a successful trial", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+		var transitions []string
+		var handlerErr error
+		var called int
+		handler := func(ctx context.Context, m *pubsub.Message) error {
+			called++
+			return handlerErr
+		}
+		interceptor := SubscriptionInterceptor(
+			withClock(clock),
+			WithMinRequests(4),
+			WithFailureRateThreshold(0.5),
+			WithCooldown(10*time.Second),
+			WithStateChangeFunc(func(info *pm.SubscriptionInfo, from, to State) {
+				transitions = append(transitions, info.SubscriptionID+":"+from.String()+"->"+to.String())
+			}),
+		)
+		h := interceptor(testSubInfo, handler)
+
+		_ = h(context.Background(), &pubsub.Message{})
+		_ = h(context.Background(), &pubsub.Message{})
+		handlerErr = errors.New("error")
+		_ = h(context.Background(), &pubsub.Message{})
+		_ = h(context.Background(), &pubsub.Message{})
+		if called != 4 {
+			t.Fatalf("The handler is expected to be called 4 times, got: %v", called)
+		}
+
+		if err := h(context.Background(), &pubsub.Message{}); !errors.Is(err, ErrOpen) {
+			t.Errorf("ErrOpen is expected to be returned while open, got: %v", err)
+		}
+		if called != 4 {
+			t.Errorf("The handler must not be called while open, got: %v calls", called)
+		}
+
+		clock.Add(10 * time.Second)
+		handlerErr = nil
+		if err := h(context.Background(), &pubsub.Message{}); err != nil {
+			t.Errorf("The trial message is expected to be handled, got: %v", err)
+		}
+
+		want := []string{"test-sub:closed->open", "test-sub:open->half-open", "test-sub:half-open->closed"}
+		if len(transitions) != len(want) {
+			t.Fatalf("transitions got: %v, want: %v", transitions, want)
+		}
+		for i := range want {
+			if transitions[i] != want[i] {
+				t.Errorf("transitions got: %v, want: %v", transitions, want)
+			}
+		}
+	})
+
+	t.Run("opens again when the trial fails", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+		handler := func(ctx context.Context, m *pubsub.Message) error {
+			return errors.New("error")
+		}
+		var last State
+		interceptor := SubscriptionInterceptor(
+			withClock(clock),
+			WithMinRequests(1),
+			WithCooldown(10*time.Second),
+			WithStateChangeFunc(func(_ *pm.SubscriptionInfo, _, to State) {
+				last = to
+			}),
+		)
+		h := interceptor(testSubInfo, handler)
+
+		_ = h(context.Background(), &pubsub.Message{})
+		clock.Add(10 * time.Second)
+		_ = h(context.Background(), &pubsub.Message{})
+		if last != StateOpen {
+			t.Errorf("The circuit breaker is expected to be open, got: %v", last)
+		}
+		if err := h(context.Background(), &pubsub.Message{}); !errors.Is(err, ErrOpen) {
+			t.Errorf("ErrOpen is expected to be returned while open, got: %v", err)
+		}
+	})
+
+	t.Run("a panicking trial counts as a failure and lets the next trial through after the cooldown", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+		var handlerErr error = errors.New("error")
+		var panics bool
+		handler := func(ctx context.Context, m *pubsub.Message) error {
+			if panics {
+				panic("test")
+			}
+			return handlerErr
+		}
+		var last State
+		interceptor := SubscriptionInterceptor(
+			withClock(clock),
+			WithMinRequests(1),
+			WithCooldown(10*time.Second),
+			WithStateChangeFunc(func(_ *pm.SubscriptionInfo, _, to State) {
+				last = to
+			}),
+		)
+		h := interceptor(testSubInfo, handler)
+
+		_ = h(context.Background(), &pubsub.Message{})
+		clock.Add(10 * time.Second)
+		panics = true
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("The panic is expected to be propagated")
+				}
+			}()
+			_ = h(context.Background(), &pubsub.Message{})
+		}()
+		if last != StateOpen {
+			t.Errorf("The circuit breaker is expected to be open after the panicking trial, got: %v", last)
+		}
+
+		clock.Add(10 * time.Second)
+		panics = false
+		handlerErr = nil
+		if err := h(context.Background(), &pubsub.Message{}); err != nil {
+			t.Errorf("The next trial message is expected to be handled, got: %v", err)
+		}
+		if last != StateClosed {
+			t.Errorf("The circuit breaker is expected to be closed, got: %v", last)
+		}
+	})
+
+	t.Run("failures out of the window are not counted", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+		handler := func(ctx context.Context, m *pubsub.Message) error {
+			return errors.New("error")
+		}
+		interceptor := SubscriptionInterceptor(
+			withClock(clock),
+			WithMinRequests(2),
+			WithWindow(10*time.Second),
+		)
+		h := interceptor(testSubInfo, handler)
+
+		_ = h(context.Background(), &pubsub.Message{})
+		clock.Add(20 * time.Second)
+		if err := h(context.Background(), &pubsub.Message{}); errors.Is(err, ErrOpen) {
+			t.Error("The circuit breaker must not open with a failure out of the window")
+		}
+	})
+
+	t.Run("holds messages until half-open with pause option", func(t *testing.T) {
+		t.Parallel()
+
+		var fail = true
+		handler := func(ctx context.Context, m *pubsub.Message) error {
+			if fail {
+				return errors.New("error")
+			}
+			return nil
+		}
+		interceptor := SubscriptionInterceptor(
+			WithMinRequests(1),
+			WithCooldown(100*time.Millisecond),
+			WithPauseWhileOpen(),
+		)
+		h := interceptor(testSubInfo, handler)
+
+		_ = h(context.Background(), &pubsub.Message{})
+		fail = false
+		start := time.Now()
+		if err := h(context.Background(), &pubsub.Message{}); err != nil {
+			t.Errorf("The held message is expected to be handled after the cooldown, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("The message is expected to be held while open, but returned in %v", elapsed)
+		}
+	})
+}
+
+func TestSubscriptionInterceptor_invalidOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  []Option
+	}{
+		{name: "zero window", opt: []Option{WithWindow(0)}},
+		{name: "window shorter than the buckets", opt: []Option{WithWindow(9 * time.Nanosecond)}},
+		{name: "zero failure rate threshold", opt: []Option{WithFailureRateThreshold(0)}},
+		{name: "failure rate threshold over 1", opt: []Option{WithFailureRateThreshold(1.5)}},
+		{name: "negative min requests", opt: []Option{WithMinRequests(-1)}},
+		{name: "negative cooldown", opt: []Option{WithCooldown(-time.Second)}},
+		{name: "zero half-open max requests", opt: []Option{WithHalfOpenMaxRequests(0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("SubscriptionInterceptor is expected to panic")
+				}
+			}()
+			SubscriptionInterceptor(tt.opt...)
+		})
+	}
+}
@@ -3,35 +3,99 @@ package pm_effectively_once
 import (
 	"context"
 	"errors"
+	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/rs/xid"
 )
 
+const (
+	datastoreStateInProgress = "in_progress"
+	datastoreStateCompleted  = "completed"
+)
+
+// datastoreEntity is the processing state of a de-duplicate key.
+// Entities without state, which were written by the older versions, are regarded as completed.
+type datastoreEntity struct {
+	State          string    `datastore:"state,noindex"`
+	Token          string    `datastore:"token,noindex"`
+	LeaseExpiresAt time.Time `datastore:"lease_expires_at,noindex"`
+}
+
 type datastoreMutexer struct {
 	kind     string
 	dsClient *datastore.Client
+	opts     mutexerOptions
 }
 
-func NewDatastoreMutexer(kind string, dsClient *datastore.Client) Mutexer {
-	return &datastoreMutexer{kind: kind, dsClient: dsClient}
+// NewDatastoreMutexer initializes a Mutexer which records the processing state in Datastore.
+func NewDatastoreMutexer(kind string, dsClient *datastore.Client, opt ...MutexerOption) Mutexer {
+	return &datastoreMutexer{kind: kind, dsClient: dsClient, opts: newMutexerOptions(opt)}
 }
 
 func (d *datastoreMutexer) RunInTx(ctx context.Context, deduplicateKey string, f func() error) error {
 	key := datastore.NameKey(d.kind, deduplicateKey, nil)
+	token := xid.New().String()
+
+	var processed bool
 	_, err := d.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
-		if err := tx.Get(key, &struct{}{}); err == nil {
-			// the event already processed
-			return nil
-		} else {
-			if !errors.Is(err, datastore.ErrNoSuchEntity) {
-				return err
+		var entity datastoreEntity
+		if err := tx.Get(key, &entity); err == nil {
+			if entity.State != datastoreStateInProgress {
+				// the event already processed
+				processed = true
+				return nil
 			}
-		}
-		if _, err := tx.Put(key, &struct{}{}); err != nil {
+			if time.Now().Before(entity.LeaseExpiresAt) {
+				return ErrInProgress
+			}
+			// the lease of a crashed worker expired, so it's taken over.
+		} else if !errors.Is(err, datastore.ErrNoSuchEntity) {
 			return err
 		}
+		_, err := tx.Put(key, &datastoreEntity{
+			State:          datastoreStateInProgress,
+			Token:          token,
+			LeaseExpiresAt: time.Now().Add(d.opts.leaseDuration),
+		})
+		return err
+	})
+	if err != nil || processed {
+		return err
+	}
 
-		return f()
+	err = runWithHeartbeat(ctx, d.opts.leaseDuration, func(ctx context.Context) error {
+		return d.updateLease(ctx, key, token, func(tx *datastore.Transaction, entity *datastoreEntity) error {
+			entity.LeaseExpiresAt = time.Now().Add(d.opts.leaseDuration)
+			_, err := tx.Put(key, entity)
+			return err
+		})
+	}, f)
+	if err != nil {
+		// release the lease so that the message can be retried right away.
+		releaseErr := d.updateLease(context.WithoutCancel(ctx), key, token, func(tx *datastore.Transaction, _ *datastoreEntity) error {
+			return tx.Delete(key)
+		})
+		return errors.Join(err, releaseErr)
+	}
+	_, err = d.dsClient.Put(context.WithoutCancel(ctx), key, &datastoreEntity{State: datastoreStateCompleted})
+	return err
+}
+
+// updateLease runs f in a transaction only when the lease is still held by the token.
+func (d *datastoreMutexer) updateLease(ctx context.Context, key *datastore.Key, token string, f func(tx *datastore.Transaction, entity *datastoreEntity) error) error {
+	_, err := d.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var entity datastoreEntity
+		if err := tx.Get(key, &entity); err != nil {
+			if errors.Is(err, datastore.ErrNoSuchEntity) {
+				return nil
+			}
+			return err
+		}
+		if entity.State != datastoreStateInProgress || entity.Token != token {
+			return nil
+		}
+		return f(tx, &entity)
 	})
 	return err
 }
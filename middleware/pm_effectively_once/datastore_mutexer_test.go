@@ -63,4 +63,42 @@ func Test_datastoreMutexer_RunInTx(t *testing.T) {
 			t.Errorf("datastoreMutexer.RunInTx must process an event with not processed id")
 		}
 	})
+
+	t.Run("a concurrent event with the same de-duplicate key is not processed but returns ErrInProgress", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewDatastoreMutexer(randString(t, 20), dsClient)
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- mutexer.RunInTx(context.Background(), "test", func() error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+		select {
+		case <-started:
+		case err := <-errCh:
+			t.Fatalf("datastoreMutexer.RunInTx is expected to process the event, but got err: %v", err)
+		}
+
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if !errors.Is(err, ErrInProgress) {
+			t.Errorf("datastoreMutexer.RunInTx is expected to return ErrInProgress, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("datastoreMutexer.RunInTx must not process an event in progress")
+		}
+
+		close(finish)
+		if err := <-errCh; err != nil {
+			t.Errorf("datastoreMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+	})
 }
@@ -0,0 +1,42 @@
+package pm_effectively_once
+
+import (
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm/internal/jsonkey"
+)
+
+// DeduplicateKeyFunc returns the de-duplicate key of the message.
+// When it returns an empty key, the message ID is used as the de-duplicate key.
+type DeduplicateKeyFunc func(m *pubsub.Message) (string, error)
+
+// AttributeKey returns the DeduplicateKeyFunc which extracts the key from the given attributes.
+// Multiple attributes are joined with ':' to make a composite key.
+// When any of the attributes is not set, it returns an empty key.
+func AttributeKey(attributes ...string) DeduplicateKeyFunc {
+	return func(m *pubsub.Message) (string, error) {
+		values := make([]string, 0, len(attributes))
+		for _, attribute := range attributes {
+			v, ok := m.Attributes[attribute]
+			if !ok {
+				return "", nil
+			}
+			values = append(values, v)
+		}
+		return strings.Join(values, ":"), nil
+	}
+}
+
+// JSONKey returns the DeduplicateKeyFunc which extracts the key from the field of the JSON payload.
+// Nested fields can be specified with multiple field names such as JSONKey("event", "id").
+func JSONKey(fields ...string) DeduplicateKeyFunc {
+	return func(m *pubsub.Message) (string, error) {
+		key, err := jsonkey.Lookup(m.Data, fields...)
+		if err != nil {
+			return "", fmt.Errorf("decode payload of message '%s': %w", m.ID, err)
+		}
+		return key, nil
+	}
+}
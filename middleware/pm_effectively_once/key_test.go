@@ -0,0 +1,77 @@
+package pm_effectively_once
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+func TestAttributeKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		attributes []string
+		m          *pubsub.Message
+		want       string
+	}{
+		{
+			name:       "single attribute",
+			attributes: []string{"event_id"},
+			m:          &pubsub.Message{Attributes: map[string]string{"event_id": "event"}},
+			want:       "event",
+		},
+		{
+			name:       "composite of attributes",
+			attributes: []string{"tenant_id", "event_id"},
+			m:          &pubsub.Message{Attributes: map[string]string{"tenant_id": "tenant", "event_id": "event"}},
+			want:       "tenant:event",
+		},
+		{
+			name:       "empty key when any of the attributes is not set",
+			attributes: []string{"tenant_id", "event_id"},
+			m:          &pubsub.Message{Attributes: map[string]string{"event_id": "event"}},
+			want:       "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AttributeKey(tt.attributes...)(tt.m)
+			if err != nil {
+				t.Fatalf("AttributeKey() is expected to return nil, but got err: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AttributeKey() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fields  []string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "string field", fields: []string{"event_id"}, data: `{"event_id":"event"}`, want: "event"},
+		{name: "nested number field", fields: []string{"event", "id"}, data: `{"event":{"id":1}}`, want: "1"},
+		{name: "integer field beyond the float64 precision", fields: []string{"event_id"}, data: `{"event_id":1234567890123456789}`, want: "1234567890123456789"},
+		{name: "missing field", fields: []string{"event_id"}, data: `{}`, want: ""},
+		{name: "invalid JSON", fields: []string{"event_id"}, data: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONKey(tt.fields...)(&pubsub.Message{Data: []byte(tt.data)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("JSONKey() err: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("JSONKey() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package pm_effectively_once
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInProgress is returned by RunInTx when a message with the same de-duplicate key is being processed.
+// The interceptor nacks the message so that it's redelivered after the processing finishes or its lease expires.
+var ErrInProgress = errors.New("pm_effectively_once: a message with the same de-duplicate key is in progress")
+
+const (
+	defaultLeaseDuration = 30 * time.Second
+	// minLeaseDuration is the precision of the lease expiry in Redis.
+	minLeaseDuration = time.Millisecond
+)
+
+type mutexerOptions struct {
+	leaseDuration time.Duration
+}
+
+// MutexerOption is an option of the Mutexers with the in-progress lease, which are the Redis and Datastore Mutexers.
+type MutexerOption func(*mutexerOptions)
+
+// WithLeaseDuration customizes how long the in-progress lease lasts without a heartbeat.
+// The lease is extended every third of the duration while the handler runs, and expires when the process dies,
+// so that the message can be retried. The duration shorter than a millisecond is raised to a millisecond.
+func WithLeaseDuration(d time.Duration) MutexerOption {
+	return func(o *mutexerOptions) {
+		o.leaseDuration = max(d, minLeaseDuration)
+	}
+}
+
+func newMutexerOptions(opt []MutexerOption) mutexerOptions {
+	opts := mutexerOptions{
+		leaseDuration: defaultLeaseDuration,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return opts
+}
+
+// runWithHeartbeat runs f while extending the lease every third of the lease duration.
+func runWithHeartbeat(ctx context.Context, leaseDuration time.Duration, extend func(ctx context.Context) error, f func() error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// a failed heartbeat is retried on the next tick, and the lease expires if it keeps failing.
+				_ = extend(ctx)
+			}
+		}
+	}()
+	err := f()
+	cancel()
+	<-done
+	return err
+}
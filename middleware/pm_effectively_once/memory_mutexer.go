@@ -1,32 +1,167 @@
 package pm_effectively_once
 
 import (
+	"container/list"
 	"context"
 	"sync"
+	"time"
 )
 
-type memoryMutexer struct {
-	sync.Mutex
-	processedKeyMap map[string]bool
+const defaultMaxEntries = 100000
+
+// MemoryStats is the statistics of MemoryMutexer.
+type MemoryStats struct {
+	// Hits is the number of events discarded as already processed.
+	Hits int64
+	// Evictions is the number of processed keys evicted by the TTL or the max entries.
+	Evictions int64
+	// Size is the number of processed keys held now.
+	Size int
+}
+
+type memoryEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// MemoryMutexer is a Mutexer which holds the processing state in memory.
+// Events with different keys are processed in parallel, and the processed keys are evicted
+// by the TTL and then the least recently used ones beyond the max entries.
+type MemoryMutexer struct {
+	mu         sync.Mutex
+	inProgress map[string]struct{}
+	// processed holds the processed keys from the most recently used one.
+	processed  *list.List
+	entries    map[string]*list.Element
+	ttl        time.Duration
+	maxEntries int
+	hits       int64
+	evictions  int64
+	now        func() time.Time
 }
 
-func NewMemoryMutexer() Mutexer {
-	return &memoryMutexer{processedKeyMap: make(map[string]bool)}
+type memoryMutexerOptions struct {
+	ttl        time.Duration
+	maxEntries int
 }
 
-func (d *memoryMutexer) RunInTx(_ context.Context, deduplicateKey string, f func() error) error {
-	d.Lock()
-	defer d.Unlock()
+// MemoryMutexerOption is an option of MemoryMutexer.
+type MemoryMutexerOption func(*memoryMutexerOptions)
 
-	if d.processedKeyMap[deduplicateKey] {
+// WithMemoryTTL customizes how long MemoryMutexer holds a processed key. 0 means no TTL.
+func WithMemoryTTL(ttl time.Duration) MemoryMutexerOption {
+	return func(o *memoryMutexerOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithMaxEntries customizes how many processed keys MemoryMutexer holds.
+// The least recently used keys are evicted beyond it.
+func WithMaxEntries(n int) MemoryMutexerOption {
+	return func(o *memoryMutexerOptions) {
+		o.maxEntries = n
+	}
+}
+
+// NewMemoryMutexer initializes a MemoryMutexer.
+// By default, up to 100000 processed keys are held without a TTL.
+// It panics when the TTL is negative or the max entries isn't positive.
+func NewMemoryMutexer(opt ...MemoryMutexerOption) *MemoryMutexer {
+	opts := memoryMutexerOptions{
+		maxEntries: defaultMaxEntries,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if opts.ttl < 0 {
+		panic("pm_effectively_once: TTL of MemoryMutexer must not be negative")
+	}
+	if opts.maxEntries <= 0 {
+		panic("pm_effectively_once: max entries of MemoryMutexer must be positive")
+	}
+	return &MemoryMutexer{
+		inProgress: make(map[string]struct{}),
+		processed:  list.New(),
+		entries:    make(map[string]*list.Element),
+		ttl:        opts.ttl,
+		maxEntries: opts.maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (d *MemoryMutexer) RunInTx(_ context.Context, deduplicateKey string, f func() error) error {
+	d.mu.Lock()
+	if d.isProcessed(deduplicateKey) {
 		// the event already processed
+		d.hits++
+		d.mu.Unlock()
 		return nil
 	}
+	if _, ok := d.inProgress[deduplicateKey]; ok {
+		d.mu.Unlock()
+		return ErrInProgress
+	}
+	// a lease is not needed since the state is lost when the process dies.
+	d.inProgress[deduplicateKey] = struct{}{}
+	d.mu.Unlock()
 
-	if err := f(); err != nil {
+	err := f()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inProgress, deduplicateKey)
+	if err != nil {
 		return err
 	}
-
-	d.processedKeyMap[deduplicateKey] = true
+	d.add(deduplicateKey)
 	return nil
 }
+
+// Stats returns the statistics of the mutexer.
+func (d *MemoryMutexer) Stats() MemoryStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return MemoryStats{
+		Hits:      d.hits,
+		Evictions: d.evictions,
+		Size:      len(d.entries),
+	}
+}
+
+func (d *MemoryMutexer) isProcessed(key string) bool {
+	e, ok := d.entries[key]
+	if !ok {
+		return false
+	}
+	if d.expired(e.Value.(*memoryEntry)) {
+		d.remove(e)
+		return false
+	}
+	d.processed.MoveToFront(e)
+	return true
+}
+
+func (d *MemoryMutexer) add(key string) {
+	entry := &memoryEntry{key: key}
+	if d.ttl > 0 {
+		entry.expiresAt = d.now().Add(d.ttl)
+	}
+	d.entries[key] = d.processed.PushFront(entry)
+
+	for e := d.processed.Back(); e != nil && d.expired(e.Value.(*memoryEntry)); e = d.processed.Back() {
+		d.remove(e)
+	}
+	for len(d.entries) > d.maxEntries {
+		d.remove(d.processed.Back())
+	}
+}
+
+func (d *MemoryMutexer) expired(entry *memoryEntry) bool {
+	return !entry.expiresAt.IsZero() && !d.now().Before(entry.expiresAt)
+}
+
+func (d *MemoryMutexer) remove(e *list.Element) {
+	d.processed.Remove(e)
+	delete(d.entries, e.Value.(*memoryEntry).key)
+	d.evictions++
+}
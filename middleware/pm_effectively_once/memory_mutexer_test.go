@@ -4,9 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 )
 
-func Test_memoryMutexer_RunInTx(t *testing.T) {
+func TestMemoryMutexer_RunInTx(t *testing.T) {
 	t.Parallel()
 
 	t.Run("an event with already processed id is not processed", func(t *testing.T) {
@@ -17,7 +18,7 @@ func Test_memoryMutexer_RunInTx(t *testing.T) {
 			return nil
 		})
 		if err != nil {
-			t.Errorf("memoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
 		}
 
 		var processed bool
@@ -26,10 +27,10 @@ func Test_memoryMutexer_RunInTx(t *testing.T) {
 			return nil
 		})
 		if err != nil {
-			t.Errorf("memoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
 		}
 		if processed {
-			t.Errorf("memoryMutexer.RunInTx must discard an event with the already processed de-duplicate key")
+			t.Errorf("MemoryMutexer.RunInTx must discard an event with the already processed de-duplicate key")
 		}
 	})
 
@@ -41,7 +42,7 @@ func Test_memoryMutexer_RunInTx(t *testing.T) {
 			return errors.New("test")
 		})
 		if err == nil {
-			t.Error("memoryMutexer.RunInTx is expected to return err, but got nil")
+			t.Error("MemoryMutexer.RunInTx is expected to return err, but got nil")
 		}
 
 		var processed bool
@@ -50,10 +51,177 @@ func Test_memoryMutexer_RunInTx(t *testing.T) {
 			return nil
 		})
 		if err != nil {
-			t.Errorf("memoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
 		}
 		if !processed {
-			t.Errorf("memoryMutexer.RunInTx must process an event with not processed de-duplicate key")
+			t.Errorf("MemoryMutexer.RunInTx must process an event with not processed de-duplicate key")
 		}
 	})
+
+	t.Run("a concurrent event with the same de-duplicate key is not processed but returns ErrInProgress", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewMemoryMutexer()
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- mutexer.RunInTx(context.Background(), "test", func() error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+		select {
+		case <-started:
+		case err := <-errCh:
+			t.Fatalf("MemoryMutexer.RunInTx is expected to process the event, but got err: %v", err)
+		}
+
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if !errors.Is(err, ErrInProgress) {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return ErrInProgress, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("MemoryMutexer.RunInTx must not process an event in progress")
+		}
+
+		close(finish)
+		if err := <-errCh; err != nil {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+	})
+	t.Run("events with different de-duplicate keys are processed in parallel", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewMemoryMutexer()
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- mutexer.RunInTx(context.Background(), "test1", func() error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+		select {
+		case <-started:
+		case err := <-errCh:
+			t.Fatalf("MemoryMutexer.RunInTx is expected to process the event, but got err: %v", err)
+		}
+
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test2", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("MemoryMutexer.RunInTx must process an event while another key is in progress")
+		}
+
+		close(finish)
+		if err := <-errCh; err != nil {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+	})
+
+	t.Run("an event is processed again after the TTL", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now()
+		mutexer := NewMemoryMutexer(WithMemoryTTL(time.Minute))
+		mutexer.now = func() time.Time { return now }
+		if err := mutexer.RunInTx(context.Background(), "test", func() error { return nil }); err != nil {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+
+		now = now.Add(time.Minute)
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("MemoryMutexer.RunInTx must process an event whose de-duplicate key is expired")
+		}
+		if got := mutexer.Stats().Evictions; got != 1 {
+			t.Errorf("Stats().Evictions = %v, want %v", got, 1)
+		}
+	})
+
+	t.Run("the least recently used key is evicted beyond the max entries", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewMemoryMutexer(WithMaxEntries(2))
+		for _, key := range []string{"test1", "test2", "test1", "test3"} {
+			if err := mutexer.RunInTx(context.Background(), key, func() error { return nil }); err != nil {
+				t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+			}
+		}
+
+		processed := map[string]bool{}
+		for _, key := range []string{"test1", "test3", "test2"} {
+			err := mutexer.RunInTx(context.Background(), key, func() error {
+				processed[key] = true
+				return nil
+			})
+			if err != nil {
+				t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+			}
+		}
+		want := map[string]bool{"test2": true}
+		if len(processed) != len(want) || !processed["test2"] {
+			t.Errorf("processed keys = %v, want %v", processed, want)
+		}
+	})
+}
+
+func TestMemoryMutexer_Stats(t *testing.T) {
+	t.Parallel()
+
+	mutexer := NewMemoryMutexer(WithMaxEntries(2))
+	for _, key := range []string{"test1", "test1", "test2", "test3", "test3"} {
+		if err := mutexer.RunInTx(context.Background(), key, func() error { return nil }); err != nil {
+			t.Errorf("MemoryMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+	}
+
+	want := MemoryStats{Hits: 2, Evictions: 1, Size: 2}
+	if got := mutexer.Stats(); got != want {
+		t.Errorf("MemoryMutexer.Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewMemoryMutexer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  []MemoryMutexerOption
+	}{
+		{name: "negative TTL", opt: []MemoryMutexerOption{WithMemoryTTL(-time.Second)}},
+		{name: "zero max entries", opt: []MemoryMutexerOption{WithMaxEntries(0)}},
+		{name: "negative max entries", opt: []MemoryMutexerOption{WithMaxEntries(-1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("NewMemoryMutexer is expected to panic")
+				}
+			}()
+			NewMemoryMutexer(tt.opt...)
+		})
+	}
 }
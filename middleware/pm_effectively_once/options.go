@@ -3,7 +3,8 @@ package pm_effectively_once
 const DefaultDeduplicateKey = "deduplicate_key"
 
 type options struct {
-	deduplicateKey string
+	deduplicateKeyFunc DeduplicateKeyFunc
+	withoutNamespace   bool
 }
 
 type Option func(*options)
@@ -11,6 +12,24 @@ type Option func(*options)
 // WithCustomDeduplicateKey customizes the attribute key for de-duplicate key.
 func WithCustomDeduplicateKey(key string) Option {
 	return func(o *options) {
-		o.deduplicateKey = key
+		o.deduplicateKeyFunc = AttributeKey(key)
+	}
+}
+
+// WithDeduplicateKeyFunc customizes the function for deriving the de-duplicate key from the message,
+// such as an event ID in the payload or a composite of attributes.
+func WithDeduplicateKeyFunc(f DeduplicateKeyFunc) Option {
+	return func(o *options) {
+		o.deduplicateKeyFunc = f
+	}
+}
+
+// WithoutSubscriptionNamespace shares the de-duplicate keys between subscriptions.
+// By default, the keys are namespaced per subscription so that each subscription on the same topic processes the message.
+// The keys stored by the older versions aren't namespaced, so use it when upgrading to keep discarding
+// the messages already processed before the deploy.
+func WithoutSubscriptionNamespace() Option {
+	return func(o *options) {
+		o.withoutNamespace = true
 	}
 }
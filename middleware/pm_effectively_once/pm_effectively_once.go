@@ -2,18 +2,30 @@ package pm_effectively_once
 
 import (
 	"context"
+	"errors"
 
 	"cloud.google.com/go/pubsub/v2"
 	"github.com/zero-color/pm"
 )
 
+// Mutexer records the processing state of de-duplicate keys.
 type Mutexer interface {
+	// RunInTx runs f unless the de-duplicate key is already processed, and marks the key as processed when f succeeds.
+	// While f runs, the key is held as in progress, and RunInTx for the key returns ErrInProgress without running f,
+	// or waits until f finishes like SQLMutexer.
 	RunInTx(ctx context.Context, deduplicateKey string, f func() error) error
 }
 
+// contextMutexer is a Mutexer which passes f the context for the handler, such as the one with the transaction.
+type contextMutexer interface {
+	RunInTxContext(ctx context.Context, deduplicateKey string, f func(ctx context.Context) error) error
+}
+
 // SubscriptionInterceptor process only the first event and discards the others with the same de-duplicate key.
 // To make this interceptor work, you need to set the de-duplicate key in the attributes when publishing message like below.
 // If the key is not set, messageID will be used as the de-duplicate key.
+// The de-duplicate key is namespaced per subscription, so subscriptions on the same topic don't clash.
+// Note that the keys stored by the older versions aren't namespaced. See WithoutSubscriptionNamespace to keep using them.
 //
 // // publisher
 // msg := pubsub.Message{Data: []byte("something"), Attributes: map[string]string{pm_effectively_once.DeduplicateKey: "unique-key"}}
@@ -27,23 +39,51 @@ type Mutexer interface {
 //			pm_effectively_once.SubscriptionInterceptor(pm_effectively_once.NewRedisMutexer(redisClient)),
 //		),
 //	)
+//
+// With SQLMutexer, the handler runs in the transaction which records the de-duplicate key,
+// so the writes via pm_effectively_once.TxFromContext(ctx) are committed atomically with it.
+//
+// The de-duplicate key can also be derived from the payload with WithDeduplicateKeyFunc.
+//
+//	pm_effectively_once.SubscriptionInterceptor(
+//		pm_effectively_once.NewRedisMutexer(redisClient),
+//		pm_effectively_once.WithDeduplicateKeyFunc(pm_effectively_once.JSONKey("event_id")),
+//	)
 func SubscriptionInterceptor(mutexer Mutexer, opt ...Option) pm.SubscriptionInterceptor {
 	opts := options{
-		deduplicateKey: DefaultDeduplicateKey,
+		deduplicateKeyFunc: AttributeKey(DefaultDeduplicateKey),
 	}
 	for _, o := range opt {
 		o(&opts)
 	}
-	return func(_ *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
 		return func(ctx context.Context, m *pubsub.Message) error {
-			deduplicateKey := m.ID
-			// when we find the deduplicateKey in the attributes, we prioritise it.
-			if keyInAttrs, ok := m.Attributes[DefaultDeduplicateKey]; ok {
-				deduplicateKey = keyInAttrs
+			deduplicateKey, err := opts.deduplicateKeyFunc(m)
+			if err != nil {
+				m.Nack()
+				return err
+			}
+			// when the de-duplicate key isn't found, we fall back to the message ID.
+			if deduplicateKey == "" {
+				deduplicateKey = m.ID
+			}
+			if !opts.withoutNamespace {
+				deduplicateKey = info.SubscriptionID + ":" + deduplicateKey
+			}
+			if cm, ok := mutexer.(contextMutexer); ok {
+				err = cm.RunInTxContext(ctx, deduplicateKey, func(ctx context.Context) error {
+					return next(ctx, m)
+				})
+			} else {
+				err = mutexer.RunInTx(ctx, deduplicateKey, func() error {
+					return next(ctx, m)
+				})
+			}
+			if errors.Is(err, ErrInProgress) {
+				// the duplicate is nacked to be redelivered in case the processing fails.
+				m.Nack()
 			}
-			return mutexer.RunInTx(ctx, deduplicateKey, func() error {
-				return next(ctx, m)
-			})
+			return err
 		}
 	}
 }
@@ -2,6 +2,7 @@ package pm_effectively_once
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 
@@ -23,21 +24,88 @@ func TestSubscriptionInterceptor(t *testing.T) {
 	next := func(ctx context.Context, m *pubsub.Message) error {
 		return nil
 	}
+	info := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
 
 	t.Run("when de-duplicate key exists in the attributes, RunInTx is called with the key", func(t *testing.T) {
 		mutexer := testMutexer{}
 		interceptor := SubscriptionInterceptor(&mutexer)
-		_ = interceptor(&pm.SubscriptionInfo{}, next)(context.Background(), &pubsub.Message{ID: "messageID", Attributes: map[string]string{DefaultDeduplicateKey: "test"}})
-		if got := mutexer.passedDeduplicateKey; got != "test" {
-			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "test")
+		_ = interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID", Attributes: map[string]string{DefaultDeduplicateKey: "test"}})
+		if got := mutexer.passedDeduplicateKey; got != "test-sub:test" {
+			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "test-sub:test")
 		}
 	})
 	t.Run("when de-duplicate key does exist in the attributes, RunInTx is called with message id", func(t *testing.T) {
 		mutexer := testMutexer{}
 		interceptor := SubscriptionInterceptor(&mutexer)
-		_ = interceptor(&pm.SubscriptionInfo{}, next)(context.Background(), &pubsub.Message{ID: "messageID", Attributes: map[string]string{}})
-		if got := mutexer.passedDeduplicateKey; got != "messageID" {
-			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "messageID")
+		_ = interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID", Attributes: map[string]string{}})
+		if got := mutexer.passedDeduplicateKey; got != "test-sub:messageID" {
+			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "test-sub:messageID")
 		}
 	})
+	t.Run("when custom de-duplicate key is set, RunInTx is called with the key in the attribute", func(t *testing.T) {
+		mutexer := testMutexer{}
+		interceptor := SubscriptionInterceptor(&mutexer, WithCustomDeduplicateKey("event_id"))
+		_ = interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID", Attributes: map[string]string{DefaultDeduplicateKey: "test", "event_id": "event"}})
+		if got := mutexer.passedDeduplicateKey; got != "test-sub:event" {
+			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "test-sub:event")
+		}
+	})
+	t.Run("when de-duplicate key func is set, RunInTx is called with the derived key", func(t *testing.T) {
+		mutexer := testMutexer{}
+		interceptor := SubscriptionInterceptor(&mutexer, WithDeduplicateKeyFunc(JSONKey("event", "id")))
+		_ = interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID", Data: []byte(`{"event":{"id":123}}`)})
+		if got := mutexer.passedDeduplicateKey; got != "test-sub:123" {
+			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "test-sub:123")
+		}
+	})
+	t.Run("when de-duplicate key func fails, the error is returned without calling RunInTx", func(t *testing.T) {
+		mutexer := testMutexer{}
+		interceptor := SubscriptionInterceptor(&mutexer, WithDeduplicateKeyFunc(JSONKey("id")))
+		err := interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID", Data: []byte("not json")})
+		if err == nil {
+			t.Error("TestSubscriptionInterceptor() is expected to return err, but got nil")
+		}
+		if mutexer.passedDeduplicateKey != "" {
+			t.Errorf("RunInTx is not expected to be called, but called with: %v", mutexer.passedDeduplicateKey)
+		}
+	})
+	t.Run("when the namespace is disabled, RunInTx is called with the key shared between subscriptions", func(t *testing.T) {
+		mutexer := testMutexer{}
+		interceptor := SubscriptionInterceptor(&mutexer, WithoutSubscriptionNamespace())
+		_ = interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID", Attributes: map[string]string{DefaultDeduplicateKey: "test"}})
+		if got := mutexer.passedDeduplicateKey; got != "test" {
+			t.Errorf("TestSubscriptionInterceptor(): got: %v, want: %v", got, "test")
+		}
+	})
+	t.Run("when the event is in progress, the error is returned", func(t *testing.T) {
+		interceptor := SubscriptionInterceptor(&inProgressMutexer{})
+		err := interceptor(info, next)(context.Background(), &pubsub.Message{ID: "messageID"})
+		if !errors.Is(err, ErrInProgress) {
+			t.Errorf("TestSubscriptionInterceptor() is expected to return ErrInProgress, but got err: %v", err)
+		}
+	})
+	t.Run("when the mutexer is SQLMutexer, the handler runs in the transaction", func(t *testing.T) {
+		db := newSQLiteDB(t)
+		interceptor := SubscriptionInterceptor(newSQLiteMutexer(t, db))
+		err := interceptor(info, func(ctx context.Context, m *pubsub.Message) error {
+			tx, ok := TxFromContext(ctx)
+			if !ok {
+				return errors.New("transaction is not found in the context")
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO items (id) VALUES ('item')")
+			return err
+		})(context.Background(), &pubsub.Message{ID: "messageID"})
+		if err != nil {
+			t.Errorf("TestSubscriptionInterceptor() is expected to return nil, but got err: %v", err)
+		}
+		if got := countItems(t, db); got != 1 {
+			t.Errorf("items = %v, want %v", got, 1)
+		}
+	})
+}
+
+type inProgressMutexer struct{}
+
+func (d *inProgressMutexer) RunInTx(_ context.Context, _ string, _ func() error) error {
+	return ErrInProgress
 }
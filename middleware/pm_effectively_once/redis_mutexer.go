@@ -2,43 +2,90 @@ package pm_effectively_once
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/rs/xid"
 )
 
+const (
+	redisCompletedValue   = "completed"
+	redisInProgressPrefix = "in_progress:"
+)
+
+// acquireScript takes the in-progress lease when the key doesn't exist.
+// It returns an empty string when the lease is taken, otherwise the current value.
+var acquireScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v then
+	return v
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return ''
+`)
+
+// extendScript extends the lease only when it's still held by the token.
+var extendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript releases the lease only when it's still held by the token.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
 type redisMutexer struct {
 	redisClient  *redis.Client
 	keyPrefix    string
 	lockDuration time.Duration
+	opts         mutexerOptions
 }
 
-func NewRedisMutexer(redisClient *redis.Client, keyPrefix string, lockDuration time.Duration) Mutexer {
+// NewRedisMutexer initializes a Mutexer which records the processing state in Redis.
+// The completed marker is kept for lockDuration, and the in-progress lease for the lease duration.
+// It takes a client of go-redis v8. Use NewUniversalRedisMutexer for go-redis v9 and cluster or failover clients.
+func NewRedisMutexer(redisClient *redis.Client, keyPrefix string, lockDuration time.Duration, opt ...MutexerOption) Mutexer {
 	return &redisMutexer{
 		redisClient:  redisClient,
 		keyPrefix:    keyPrefix,
 		lockDuration: lockDuration,
+		opts:         newMutexerOptions(opt),
 	}
 }
 
 func (d *redisMutexer) RunInTx(ctx context.Context, deduplicateKey string, f func() error) error {
 	key := d.keyPrefix + ":" + deduplicateKey
-	return d.redisClient.Watch(ctx, func(tx *redis.Tx) error {
-		if err := tx.Get(ctx, key).Err(); err == nil {
-			// the event already processed
-			return nil
-		} else {
-			if err != redis.Nil {
-				return err
-			}
-		}
-		_, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
-			if err := p.Set(ctx, key, true, d.lockDuration).Err(); err != nil {
-				return err
-			}
-
-			return f()
-		})
+	token := redisInProgressPrefix + xid.New().String()
+
+	current, err := acquireScript.Run(ctx, d.redisClient, []string{key}, token, d.opts.leaseDuration.Milliseconds()).Text()
+	if err != nil {
 		return err
-	})
+	}
+	switch {
+	case current == "":
+		// the lease is taken
+	case strings.HasPrefix(current, redisInProgressPrefix):
+		return ErrInProgress
+	default:
+		// the event already processed. the older versions marked it with "1" instead of redisCompletedValue.
+		return nil
+	}
+
+	err = runWithHeartbeat(ctx, d.opts.leaseDuration, func(ctx context.Context) error {
+		return extendScript.Run(ctx, d.redisClient, []string{key}, token, d.opts.leaseDuration.Milliseconds()).Err()
+	}, f)
+	if err != nil {
+		// release the lease so that the message can be retried right away.
+		releaseErr := releaseScript.Run(context.WithoutCancel(ctx), d.redisClient, []string{key}, token).Err()
+		return errors.Join(err, releaseErr)
+	}
+	return d.redisClient.Set(context.WithoutCancel(ctx), key, redisCompletedValue, d.lockDuration).Err()
 }
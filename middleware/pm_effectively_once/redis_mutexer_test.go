@@ -62,4 +62,110 @@ func Test_redisMutexer_RunInTx(t *testing.T) {
 			t.Errorf("memoryMutexer.RunInTx must process an event with not processed id")
 		}
 	})
+
+	t.Run("a concurrent event with the same de-duplicate key is not processed but returns ErrInProgress", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewRedisMutexer(redisClient, randString(t, 20), 1*time.Hour)
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- mutexer.RunInTx(context.Background(), "test", func() error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+		select {
+		case <-started:
+		case err := <-errCh:
+			t.Fatalf("redisMutexer.RunInTx is expected to process the event, but got err: %v", err)
+		}
+
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if !errors.Is(err, ErrInProgress) {
+			t.Errorf("redisMutexer.RunInTx is expected to return ErrInProgress, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("redisMutexer.RunInTx must not process an event in progress")
+		}
+
+		close(finish)
+		if err := <-errCh; err != nil {
+			t.Errorf("redisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+	})
+
+	t.Run("the lease is extended while the event is processed", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewRedisMutexer(redisClient, randString(t, 20), 1*time.Hour, WithLeaseDuration(300*time.Millisecond))
+		var duplicateErr error
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			time.Sleep(600 * time.Millisecond)
+			duplicateErr = mutexer.RunInTx(context.Background(), "test", func() error {
+				return nil
+			})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("redisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !errors.Is(duplicateErr, ErrInProgress) {
+			t.Errorf("The lease is expected to be held beyond the lease duration, but got err: %v", duplicateErr)
+		}
+	})
+
+	t.Run("an event marked as processed by the older versions is not processed", func(t *testing.T) {
+		t.Parallel()
+
+		keyPrefix := randString(t, 20)
+		if err := redisClient.Set(context.Background(), keyPrefix+":test", true, 1*time.Hour).Err(); err != nil {
+			t.Fatal(err)
+		}
+		mutexer := NewRedisMutexer(redisClient, keyPrefix, 1*time.Hour)
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("redisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("redisMutexer.RunInTx must discard an event with the already processed id")
+		}
+	})
+
+	t.Run("the lease of a crashed worker expires and the event is retried", func(t *testing.T) {
+		t.Parallel()
+
+		keyPrefix := randString(t, 20)
+		// a crashed worker leaves the in-progress lease without a heartbeat.
+		if err := redisClient.Set(context.Background(), keyPrefix+":test", redisInProgressPrefix+"crashed", 200*time.Millisecond).Err(); err != nil {
+			t.Fatal(err)
+		}
+		mutexer := NewRedisMutexer(redisClient, keyPrefix, 1*time.Hour)
+		if err := mutexer.RunInTx(context.Background(), "test", func() error { return nil }); !errors.Is(err, ErrInProgress) {
+			t.Errorf("redisMutexer.RunInTx is expected to return ErrInProgress, but got err: %v", err)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("redisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("redisMutexer.RunInTx must process an event whose lease expired")
+		}
+	})
 }
@@ -0,0 +1,197 @@
+package pm_effectively_once
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// SQLDialect is the SQL dialect of the database used by SQLMutexer.
+type SQLDialect int
+
+const (
+	// SQLDialectPostgres is the dialect for PostgreSQL.
+	SQLDialectPostgres SQLDialect = iota
+	// SQLDialectMySQL is the dialect for MySQL.
+	SQLDialectMySQL
+	// SQLDialectSQLite is the dialect for SQLite.
+	SQLDialectSQLite
+)
+
+const defaultTableName = "pm_effectively_once"
+
+// createTableQuery is common to the dialects.
+// key_hash is the SHA-256 of the de-duplicate key, so that the primary key has a fixed length however long the key is.
+// token identifies the transaction which inserted the row, and expires_at is the expiry in Unix milliseconds, and NULL never expires.
+func (d SQLDialect) createTableQuery(table string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (key_hash CHAR(64) NOT NULL PRIMARY KEY, dedup_key TEXT NOT NULL, token VARCHAR(32) NOT NULL, expires_at BIGINT)", table)
+}
+
+func (d SQLDialect) deleteExpiredQuery(table string) string {
+	return d.rebind(fmt.Sprintf("DELETE FROM %s WHERE key_hash = ? AND expires_at <= ?", table))
+}
+
+// insertQuery inserts the de-duplicate key, and does nothing when the key already exists.
+func (d SQLDialect) insertQuery(table string) string {
+	switch d {
+	case SQLDialectMySQL:
+		return fmt.Sprintf("INSERT IGNORE INTO %s (key_hash, dedup_key, token, expires_at) VALUES (?, ?, ?, ?)", table)
+	default:
+		return d.rebind(fmt.Sprintf("INSERT INTO %s (key_hash, dedup_key, token, expires_at) VALUES (?, ?, ?, ?) ON CONFLICT (key_hash) DO NOTHING", table))
+	}
+}
+
+// selectTokenQuery reads the latest token of the key. SQLite locks the whole database in a write transaction instead of rows.
+func (d SQLDialect) selectTokenQuery(table string) string {
+	switch d {
+	case SQLDialectSQLite:
+		return fmt.Sprintf("SELECT token FROM %s WHERE key_hash = ?", table)
+	default:
+		return d.rebind(fmt.Sprintf("SELECT token FROM %s WHERE key_hash = ? FOR UPDATE", table))
+	}
+}
+
+// rebind replaces the ? placeholders with $1, $2, ... for Postgres.
+func (d SQLDialect) rebind(query string) string {
+	if d != SQLDialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type txContextKey struct{}
+
+// TxFromContext returns the transaction in which SQLMutexer runs the handler.
+// The writes in the transaction are committed atomically with the de-duplicate key.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// SQLMutexer is a Mutexer which records the processed de-duplicate keys in a table of a SQL database.
+// The key is inserted in a transaction, and the handler runs in the same transaction, which is available via TxFromContext.
+// While the transaction is open, the row lock of the key makes the duplicates wait, and they are discarded when it's committed.
+type SQLMutexer struct {
+	db    *sql.DB
+	table string
+	opts  sqlMutexerOptions
+}
+
+type sqlMutexerOptions struct {
+	dialect   SQLDialect
+	tableName string
+	ttl       time.Duration
+}
+
+// SQLMutexerOption is an option of SQLMutexer.
+type SQLMutexerOption func(*sqlMutexerOptions)
+
+// WithSQLDialect customizes the SQL dialect of SQLMutexer. The default is SQLDialectPostgres.
+func WithSQLDialect(dialect SQLDialect) SQLMutexerOption {
+	return func(o *sqlMutexerOptions) {
+		o.dialect = dialect
+	}
+}
+
+// WithTableName customizes the table in which SQLMutexer records the de-duplicate keys.
+func WithTableName(table string) SQLMutexerOption {
+	return func(o *sqlMutexerOptions) {
+		o.tableName = table
+	}
+}
+
+// WithSQLTTL customizes how long SQLMutexer holds a processed key. 0 means the keys never expire.
+func WithSQLTTL(ttl time.Duration) SQLMutexerOption {
+	return func(o *sqlMutexerOptions) {
+		o.ttl = ttl
+	}
+}
+
+// NewSQLMutexer initializes a SQLMutexer.
+// By default, it uses the Postgres dialect and the "pm_effectively_once" table, and the keys never expire.
+// It panics when the dialect is unknown, the table name is empty or the TTL is negative.
+func NewSQLMutexer(db *sql.DB, opt ...SQLMutexerOption) *SQLMutexer {
+	opts := sqlMutexerOptions{
+		dialect:   SQLDialectPostgres,
+		tableName: defaultTableName,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	switch {
+	case opts.dialect < SQLDialectPostgres || opts.dialect > SQLDialectSQLite:
+		panic(fmt.Sprintf("pm_effectively_once: unknown SQL dialect: %d", opts.dialect))
+	case opts.tableName == "":
+		panic("pm_effectively_once: table name of SQLMutexer must not be empty")
+	case opts.ttl < 0:
+		panic("pm_effectively_once: TTL of SQLMutexer must not be negative")
+	}
+	return &SQLMutexer{db: db, table: opts.tableName, opts: opts}
+}
+
+// CreateTable creates the table to record the de-duplicate keys if it doesn't exist.
+func (d *SQLMutexer) CreateTable(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, d.opts.dialect.createTableQuery(d.table))
+	return err
+}
+
+func (d *SQLMutexer) RunInTx(ctx context.Context, deduplicateKey string, f func() error) error {
+	return d.RunInTxContext(ctx, deduplicateKey, func(context.Context) error {
+		return f()
+	})
+}
+
+// RunInTxContext is the same as RunInTx, but passes f the context with the transaction.
+func (d *SQLMutexer) RunInTxContext(ctx context.Context, deduplicateKey string, f func(ctx context.Context) error) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	keyHash := sha256.Sum256([]byte(deduplicateKey))
+	hexKeyHash := hex.EncodeToString(keyHash[:])
+	token := xid.New().String()
+	now := time.Now()
+	var expiresAt sql.NullInt64
+	if d.opts.ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: now.Add(d.opts.ttl).UnixMilli(), Valid: true}
+	}
+	if _, err := tx.ExecContext(ctx, d.opts.dialect.deleteExpiredQuery(d.table), hexKeyHash, now.UnixMilli()); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	if _, err := tx.ExecContext(ctx, d.opts.dialect.insertQuery(d.table), hexKeyHash, deduplicateKey, token, expiresAt); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	// the affected rows of the insert depend on the driver settings, so the token tells whether this transaction inserted the key.
+	var current string
+	if err := tx.QueryRowContext(ctx, d.opts.dialect.selectTokenQuery(d.table), hexKeyHash).Scan(&current); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	if current != token {
+		// the event already processed
+		return tx.Rollback()
+	}
+
+	if err := f(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,238 @@
+package pm_effectively_once
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func newSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	// SQLite allows a single writer at a time.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec("CREATE TABLE items (id TEXT PRIMARY KEY)"); err != nil {
+		t.Fatalf("create table failed: %v", err)
+	}
+	return db
+}
+
+func newSQLiteMutexer(t *testing.T, db *sql.DB, opt ...SQLMutexerOption) *SQLMutexer {
+	t.Helper()
+
+	mutexer := NewSQLMutexer(db, append([]SQLMutexerOption{WithSQLDialect(SQLDialectSQLite)}, opt...)...)
+	if err := mutexer.CreateTable(context.Background()); err != nil {
+		t.Fatalf("SQLMutexer.CreateTable is expected to return nil, but got err: %v", err)
+	}
+	return mutexer
+}
+
+func countItems(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM items").Scan(&count); err != nil {
+		t.Fatalf("count items failed: %v", err)
+	}
+	return count
+}
+
+func TestSQLMutexer_RunInTx(t *testing.T) {
+	t.Parallel()
+
+	t.Run("an event with already processed id is not processed", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := newSQLiteMutexer(t, newSQLiteDB(t))
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			return nil
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+
+		var processed bool
+		err = mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("SQLMutexer.RunInTx must discard an event with the already processed id")
+		}
+	})
+
+	t.Run("when processing first event returns error, next event with same id is processed", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := newSQLiteMutexer(t, newSQLiteDB(t))
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			return errors.New("test")
+		})
+		if err == nil {
+			t.Error("SQLMutexer.RunInTx is expected to return err, but got nil")
+		}
+
+		var processed bool
+		err = mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("SQLMutexer.RunInTx must process an event with not processed id")
+		}
+	})
+
+	t.Run("an event with a de-duplicate key longer than 255 characters is processed once", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := newSQLiteMutexer(t, newSQLiteDB(t))
+		key := strings.Repeat("a", 255) + ":" + strings.Repeat("b", 255)
+		var processed int
+		for range 2 {
+			err := mutexer.RunInTx(context.Background(), key, func() error {
+				processed++
+				return nil
+			})
+			if err != nil {
+				t.Errorf("SQLMutexer.RunInTx is expected to return nil, but got err: %v", err)
+			}
+		}
+		if processed != 1 {
+			t.Errorf("SQLMutexer.RunInTx is expected to process the event once, but processed %v times", processed)
+		}
+	})
+
+	t.Run("an event is processed again after the TTL", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := newSQLiteMutexer(t, newSQLiteDB(t), WithSQLTTL(time.Millisecond))
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			return nil
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var processed bool
+		err = mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("SQLMutexer.RunInTx must process an event whose id is expired")
+		}
+	})
+}
+
+func TestSQLMutexer_RunInTxContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writes in the transaction are committed with the de-duplicate key", func(t *testing.T) {
+		t.Parallel()
+
+		db := newSQLiteDB(t)
+		mutexer := newSQLiteMutexer(t, db)
+		err := mutexer.RunInTxContext(context.Background(), "test", func(ctx context.Context) error {
+			tx, ok := TxFromContext(ctx)
+			if !ok {
+				t.Fatal("TxFromContext is expected to return the transaction")
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO items (id) VALUES ('item')")
+			return err
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTxContext is expected to return nil, but got err: %v", err)
+		}
+		if got := countItems(t, db); got != 1 {
+			t.Errorf("items = %v, want %v", got, 1)
+		}
+	})
+
+	t.Run("writes in the transaction are rolled back with the de-duplicate key", func(t *testing.T) {
+		t.Parallel()
+
+		db := newSQLiteDB(t)
+		mutexer := newSQLiteMutexer(t, db)
+		err := mutexer.RunInTxContext(context.Background(), "test", func(ctx context.Context) error {
+			tx, _ := TxFromContext(ctx)
+			if _, err := tx.ExecContext(ctx, "INSERT INTO items (id) VALUES ('item')"); err != nil {
+				return err
+			}
+			return errors.New("test")
+		})
+		if err == nil {
+			t.Error("SQLMutexer.RunInTxContext is expected to return err, but got nil")
+		}
+		if got := countItems(t, db); got != 0 {
+			t.Errorf("items = %v, want %v", got, 0)
+		}
+
+		var processed bool
+		err = mutexer.RunInTxContext(context.Background(), "test", func(ctx context.Context) error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("SQLMutexer.RunInTxContext is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("SQLMutexer.RunInTxContext must process an event whose first processing is rolled back")
+		}
+	})
+}
+
+func TestSQLDialect_rebind(t *testing.T) {
+	t.Parallel()
+
+	query := "DELETE FROM t WHERE key_hash = ? AND expires_at <= ?"
+	if got, want := SQLDialectPostgres.rebind(query), "DELETE FROM t WHERE key_hash = $1 AND expires_at <= $2"; got != want {
+		t.Errorf("rebind() = %v, want %v", got, want)
+	}
+	if got := SQLDialectMySQL.rebind(query); got != query {
+		t.Errorf("rebind() = %v, want %v", got, query)
+	}
+}
+
+func TestNewSQLMutexer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  []SQLMutexerOption
+	}{
+		{name: "unknown dialect", opt: []SQLMutexerOption{WithSQLDialect(SQLDialect(100))}},
+		{name: "empty table name", opt: []SQLMutexerOption{WithTableName("")}},
+		{name: "negative TTL", opt: []SQLMutexerOption{WithSQLTTL(-time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("NewSQLMutexer is expected to panic")
+				}
+			}()
+			NewSQLMutexer(nil, tt.opt...)
+		})
+	}
+}
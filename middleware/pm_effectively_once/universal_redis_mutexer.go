@@ -0,0 +1,85 @@
+package pm_effectively_once
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	redisv9 "github.com/redis/go-redis/v9"
+	"github.com/rs/xid"
+)
+
+// acquireNXScript takes the in-progress lease with SET NX.
+// It returns an empty string when the lease is taken, otherwise the current value.
+var acquireNXScript = redisv9.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return ''
+end
+return redis.call('GET', KEYS[1])
+`)
+
+// extendNXScript extends the lease only when it's still held by the token.
+var extendNXScript = redisv9.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseNXScript releases the lease only when it's still held by the token.
+var releaseNXScript = redisv9.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type universalRedisMutexer struct {
+	redisClient  redisv9.UniversalClient
+	keyPrefix    string
+	lockDuration time.Duration
+	opts         mutexerOptions
+}
+
+// NewUniversalRedisMutexer initializes a Mutexer which records the processing state in Redis with go-redis v9.
+// It accepts any redis.UniversalClient, so standalone, cluster and failover clients can be used.
+// Every script touches a single key, so it works across the slots of a cluster.
+// The completed marker is kept for lockDuration, and the in-progress lease for the lease duration.
+func NewUniversalRedisMutexer(redisClient redisv9.UniversalClient, keyPrefix string, lockDuration time.Duration, opt ...MutexerOption) Mutexer {
+	return &universalRedisMutexer{
+		redisClient:  redisClient,
+		keyPrefix:    keyPrefix,
+		lockDuration: lockDuration,
+		opts:         newMutexerOptions(opt),
+	}
+}
+
+func (d *universalRedisMutexer) RunInTx(ctx context.Context, deduplicateKey string, f func() error) error {
+	key := d.keyPrefix + ":" + deduplicateKey
+	token := redisInProgressPrefix + xid.New().String()
+
+	current, err := acquireNXScript.Run(ctx, d.redisClient, []string{key}, token, d.opts.leaseDuration.Milliseconds()).Text()
+	if err != nil {
+		return err
+	}
+	switch {
+	case current == "":
+		// the lease is taken
+	case strings.HasPrefix(current, redisInProgressPrefix):
+		return ErrInProgress
+	default:
+		// the event already processed, possibly marked by NewRedisMutexer of the older versions.
+		return nil
+	}
+
+	err = runWithHeartbeat(ctx, d.opts.leaseDuration, func(ctx context.Context) error {
+		return extendNXScript.Run(ctx, d.redisClient, []string{key}, token, d.opts.leaseDuration.Milliseconds()).Err()
+	}, f)
+	if err != nil {
+		// release the lease so that the message can be retried right away.
+		releaseErr := releaseNXScript.Run(context.WithoutCancel(ctx), d.redisClient, []string{key}, token).Err()
+		return errors.Join(err, releaseErr)
+	}
+	return d.redisClient.Set(context.WithoutCancel(ctx), key, redisCompletedValue, d.lockDuration).Err()
+}
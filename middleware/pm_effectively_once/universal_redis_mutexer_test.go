@@ -0,0 +1,150 @@
+package pm_effectively_once
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+func Test_universalRedisMutexer_RunInTx(t *testing.T) {
+	t.Parallel()
+
+	redisClient := redisv9.NewUniversalClient(&redisv9.UniversalOptions{Addrs: []string{os.Getenv("REDIS_URL")}})
+
+	t.Run("an event with already processed id is not processed", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewUniversalRedisMutexer(redisClient, randString(t, 20), 1*time.Hour)
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			return nil
+		})
+		if err != nil {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+
+		var processed bool
+		err = mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("universalRedisMutexer.RunInTx must discard an event with the already processed id")
+		}
+	})
+
+	t.Run("when processing first event returns error, next event with same id is processed", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewUniversalRedisMutexer(redisClient, randString(t, 20), 1*time.Hour)
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			return errors.New("test")
+		})
+		if err == nil {
+			t.Error("universalRedisMutexer.RunInTx is expected to return err, but got nil")
+		}
+
+		var processed bool
+		err = mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("universalRedisMutexer.RunInTx must process an event with not processed id")
+		}
+	})
+
+	t.Run("a concurrent event with the same de-duplicate key is not processed but returns ErrInProgress", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewUniversalRedisMutexer(redisClient, randString(t, 20), 1*time.Hour)
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- mutexer.RunInTx(context.Background(), "test", func() error {
+				close(started)
+				<-finish
+				return nil
+			})
+		}()
+		select {
+		case <-started:
+		case err := <-errCh:
+			t.Fatalf("universalRedisMutexer.RunInTx is expected to process the event, but got err: %v", err)
+		}
+
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if !errors.Is(err, ErrInProgress) {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return ErrInProgress, but got err: %v", err)
+		}
+		if processed {
+			t.Errorf("universalRedisMutexer.RunInTx must not process an event in progress")
+		}
+
+		close(finish)
+		if err := <-errCh; err != nil {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+	})
+
+	t.Run("the lease is extended while the event is processed", func(t *testing.T) {
+		t.Parallel()
+
+		mutexer := NewUniversalRedisMutexer(redisClient, randString(t, 20), 1*time.Hour, WithLeaseDuration(300*time.Millisecond))
+		var duplicateErr error
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			time.Sleep(600 * time.Millisecond)
+			duplicateErr = mutexer.RunInTx(context.Background(), "test", func() error {
+				return nil
+			})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !errors.Is(duplicateErr, ErrInProgress) {
+			t.Errorf("The lease is expected to be held beyond the lease duration, but got err: %v", duplicateErr)
+		}
+	})
+
+	t.Run("the lease of a crashed worker expires and the event is retried", func(t *testing.T) {
+		t.Parallel()
+
+		keyPrefix := randString(t, 20)
+		// a crashed worker leaves the in-progress lease without a heartbeat.
+		if err := redisClient.Set(context.Background(), keyPrefix+":test", redisInProgressPrefix+"crashed", 200*time.Millisecond).Err(); err != nil {
+			t.Fatal(err)
+		}
+		mutexer := NewUniversalRedisMutexer(redisClient, keyPrefix, 1*time.Hour)
+		if err := mutexer.RunInTx(context.Background(), "test", func() error { return nil }); !errors.Is(err, ErrInProgress) {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return ErrInProgress, but got err: %v", err)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		var processed bool
+		err := mutexer.RunInTx(context.Background(), "test", func() error {
+			processed = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("universalRedisMutexer.RunInTx is expected to return nil, but got err: %v", err)
+		}
+		if !processed {
+			t.Errorf("universalRedisMutexer.RunInTx must process an event whose lease expired")
+		}
+	})
+}
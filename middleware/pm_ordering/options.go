@@ -0,0 +1,41 @@
+package pm_ordering
+
+import (
+	"time"
+)
+
+// FailureFunc is a function called when publishing a message with the ordering key fails.
+type FailureFunc func(topicID string, orderingKey string, err error)
+
+type options struct {
+	resume      bool
+	maxRetries  int
+	backoff     time.Duration
+	failureFunc FailureFunc
+}
+
+type Option func(*options)
+
+// WithRetry retries publishing up to maxRetries times with the backoff after resuming the ordering key.
+// It can't be used with WithoutResume.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.maxRetries = maxRetries
+		o.backoff = backoff
+	}
+}
+
+// WithoutResume keeps the ordering key paused after a failure.
+// Publishing with the key fails until Publisher.ResumePublish is called, so it can't be used with WithRetry.
+func WithoutResume() Option {
+	return func(o *options) {
+		o.resume = false
+	}
+}
+
+// WithFailureFunc sets the function called with the affected ordering key when publishing fails.
+func WithFailureFunc(f FailureFunc) Option {
+	return func(o *options) {
+		o.failureFunc = f
+	}
+}
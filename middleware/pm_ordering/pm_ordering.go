@@ -0,0 +1,79 @@
+package pm_ordering
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// PublishInterceptor makes the failure of publishing with an ordering key predictable.
+// When publishing with an ordering key fails, the Pub/Sub client pauses the key until Publisher.ResumePublish is called.
+// This interceptor resumes the key, reports it with the failure func, and optionally retries publishing in the background.
+// The returned PublishResult becomes ready after the last attempt, and holds the result of it.
+// The retries are published with context.WithoutCancel(ctx), so they aren't stopped by the cancellation of the caller.
+// Note that retried messages can be published after the messages published with the same key in the meantime.
+// It panics when WithRetry is combined with WithoutResume, or the retry count or the backoff is negative.
+//
+// // publisher
+// pubsubPublisher := pm.NewPublisher(
+//
+//		pubsubClient,
+//		pm.WithPublishInterceptor(
+//			pm_ordering.PublishInterceptor(pm_ordering.WithRetry(3, 100*time.Millisecond)),
+//		),
+//	)
+func PublishInterceptor(opt ...Option) pm.PublishInterceptor {
+	opts := options{
+		resume: true,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	switch {
+	case opts.maxRetries < 0:
+		panic(fmt.Sprintf("pm_ordering: max retries must not be negative, got: %d", opts.maxRetries))
+	case opts.backoff < 0:
+		panic(fmt.Sprintf("pm_ordering: backoff must not be negative, got: %v", opts.backoff))
+	case opts.maxRetries > 0 && !opts.resume:
+		// the retries would fail against the paused ordering key.
+		panic("pm_ordering: WithRetry can't be used with WithoutResume")
+	}
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			if m.OrderingKey == "" {
+				return next(ctx, publisher, m)
+			}
+
+			result := newPendingResult()
+			r := next(ctx, publisher, m)
+			go func() {
+				ctx := context.WithoutCancel(ctx)
+				for retries := 0; ; retries++ {
+					serverID, err := r.Get(ctx)
+					if err == nil {
+						result.set(serverID, nil)
+						return
+					}
+
+					if opts.resume {
+						publisher.ResumePublish(m.OrderingKey)
+					}
+					if opts.failureFunc != nil {
+						opts.failureFunc(publisher.ID(), m.OrderingKey, err)
+					}
+					if retries >= opts.maxRetries {
+						result.set("", err)
+						return
+					}
+
+					time.Sleep(opts.backoff)
+					r = next(ctx, publisher, m)
+				}
+			}()
+			return result
+		}
+	}
+}
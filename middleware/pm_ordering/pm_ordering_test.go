@@ -0,0 +1,198 @@
+package pm_ordering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/zero-color/pm"
+)
+
+func TestPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	t.Cleanup(func() { _ = ts.Close() })
+
+	newOrderedPublisher := func(topicName string) *pubsub.Publisher {
+		publisher := ts.Client.Publisher(topicName)
+		publisher.EnableMessageOrdering = true
+		return publisher
+	}
+
+	t.Run("resumes the ordering key and reports it when publishing fails", func(t *testing.T) {
+		t.Parallel()
+
+		topicName := fmt.Sprintf("projects/test-project/topics/TestPublishInterceptor_resume_%d", time.Now().UnixNano())
+		publisher := newOrderedPublisher(topicName)
+		defer publisher.Stop()
+
+		reportedKeys := make(chan string, 1)
+		pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(
+			PublishInterceptor(WithFailureFunc(func(topicID string, orderingKey string, err error) {
+				reportedKeys <- orderingKey
+			})),
+		))
+
+		// the topic doesn't exist yet
+		_, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx)
+		if err == nil {
+			t.Fatal("Publish() is expected to return err, but got nil")
+		}
+		if got := <-reportedKeys; got != "key" {
+			t.Errorf("The failure func is expected to be called with the ordering key, got: %v", got)
+		}
+
+		if _, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{Name: topicName}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx); err != nil {
+			t.Errorf("Publish() with the resumed ordering key is expected to return nil, but got err: %v", err)
+		}
+	})
+
+	t.Run("retries publishing after resuming the ordering key", func(t *testing.T) {
+		t.Parallel()
+
+		topicName := fmt.Sprintf("projects/test-project/topics/TestPublishInterceptor_retry_%d", time.Now().UnixNano())
+		publisher := newOrderedPublisher(topicName)
+		defer publisher.Stop()
+
+		var once sync.Once
+		attempts := make(chan pm.PublishResult, 2)
+		recordAttempt := func(next pm.MessagePublisher) pm.MessagePublisher {
+			return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+				result := next(ctx, publisher, m)
+				attempts <- result
+				return result
+			}
+		}
+		pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(
+			PublishInterceptor(
+				WithRetry(1, 10*time.Millisecond),
+				WithFailureFunc(func(topicID string, orderingKey string, err error) {
+					once.Do(func() {
+						if _, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{Name: topicName}); err != nil {
+							t.Error(err)
+						}
+					})
+				}),
+			),
+			recordAttempt,
+		))
+
+		serverID, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx)
+		if err != nil {
+			t.Errorf("Publish() is expected to return the result of the retry, but got err: %v", err)
+		}
+		if serverID == "" {
+			t.Error("Publish() is expected to return the server id of the retry")
+		}
+		if len(attempts) != 2 {
+			t.Errorf("Publishing is expected to be attempted twice, but attempted %v times", len(attempts))
+		}
+	})
+
+	t.Run("returns the err of the last attempt when the retries fail", func(t *testing.T) {
+		t.Parallel()
+
+		topicName := fmt.Sprintf("projects/test-project/topics/TestPublishInterceptor_retry_fail_%d", time.Now().UnixNano())
+		publisher := newOrderedPublisher(topicName)
+		defer publisher.Stop()
+
+		var failures int
+		pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(
+			PublishInterceptor(
+				WithRetry(2, time.Millisecond),
+				WithFailureFunc(func(topicID string, orderingKey string, err error) {
+					failures++
+				}),
+			),
+		))
+
+		// the topic never exists
+		_, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx)
+		if err == nil {
+			t.Error("Publish() is expected to return err, but got nil")
+		}
+		if failures != 3 {
+			t.Errorf("The failure func is expected to be called 3 times, but called %v times", failures)
+		}
+	})
+
+	t.Run("retries even after the ctx of the caller is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		topicName := fmt.Sprintf("projects/test-project/topics/TestPublishInterceptor_retry_canceled_%d", time.Now().UnixNano())
+		publisher := newOrderedPublisher(topicName)
+		defer publisher.Stop()
+
+		publishCtx, cancel := context.WithCancel(ctx)
+		pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(
+			PublishInterceptor(
+				WithRetry(1, 10*time.Millisecond),
+				WithFailureFunc(func(topicID string, orderingKey string, err error) {
+					cancel()
+					if _, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{Name: topicName}); err != nil {
+						t.Error(err)
+					}
+				}),
+			),
+		))
+
+		if _, err := pmPublisher.Publish(publishCtx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx); err != nil {
+			t.Errorf("Publish() is expected to return the result of the retry, but got err: %v", err)
+		}
+	})
+
+	t.Run("keeps the ordering key paused without resume", func(t *testing.T) {
+		t.Parallel()
+
+		topicName := fmt.Sprintf("projects/test-project/topics/TestPublishInterceptor_without_resume_%d", time.Now().UnixNano())
+		publisher := newOrderedPublisher(topicName)
+		defer publisher.Stop()
+
+		pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(PublishInterceptor(WithoutResume())))
+		if _, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx); err == nil {
+			t.Fatal("Publish() is expected to return err, but got nil")
+		}
+		if _, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{Name: topicName}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}).Get(ctx)
+		var pausedErr pubsub.ErrPublishingPaused
+		if !errors.As(err, &pausedErr) {
+			t.Errorf("Publish() is expected to return ErrPublishingPaused, but got err: %v", err)
+		}
+	})
+}
+
+func TestPublishInterceptor_invalidOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  []Option
+	}{
+		{name: "negative max retries", opt: []Option{WithRetry(-1, time.Millisecond)}},
+		{name: "negative backoff", opt: []Option{WithRetry(1, -time.Millisecond)}},
+		{name: "retry without resume", opt: []Option{WithoutResume(), WithRetry(1, time.Millisecond)}},
+		{name: "without resume after retry", opt: []Option{WithRetry(1, time.Millisecond), WithoutResume()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("PublishInterceptor is expected to panic")
+				}
+			}()
+			PublishInterceptor(tt.opt...)
+		})
+	}
+}
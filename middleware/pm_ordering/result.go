@@ -0,0 +1,36 @@
+package pm_ordering
+
+import (
+	"context"
+)
+
+// pendingResult is the PublishResult which becomes ready after the last attempt of publishing.
+type pendingResult struct {
+	ready    chan struct{}
+	serverID string
+	err      error
+}
+
+func newPendingResult() *pendingResult {
+	return &pendingResult{ready: make(chan struct{})}
+}
+
+// set makes the result ready. It must be called once.
+func (r *pendingResult) set(serverID string, err error) {
+	r.serverID = serverID
+	r.err = err
+	close(r.ready)
+}
+
+func (r *pendingResult) Ready() <-chan struct{} {
+	return r.ready
+}
+
+func (r *pendingResult) Get(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-r.ready:
+		return r.serverID, r.err
+	}
+}
@@ -0,0 +1,24 @@
+package pm_otel
+
+import "go.opentelemetry.io/otel/propagation"
+
+// attributesCarrier adapts the message attributes to propagation.TextMapCarrier.
+type attributesCarrier map[string]string
+
+var _ propagation.TextMapCarrier = attributesCarrier{}
+
+func (c attributesCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c attributesCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c attributesCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,191 @@
+package pm_otel
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// Outcome is the result of the message handling recorded as the "pm.outcome" attribute.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeError   Outcome = "error"
+	OutcomePanic   Outcome = "panic"
+)
+
+const outcomeKey = attribute.Key("pm.outcome")
+
+type subscriptionMetrics struct {
+	duration   metric.Float64Histogram
+	processed  metric.Int64Counter
+	e2eLatency metric.Float64Histogram
+	inFlight   metric.Int64UpDownCounter
+}
+
+func newSubscriptionMetrics(meter metric.Meter) (*subscriptionMetrics, error) {
+	duration, err := meter.Float64Histogram(
+		"messaging.process.duration",
+		metric.WithDescription("Duration of processing operation."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := meter.Int64Counter(
+		"pm.message.processed",
+		metric.WithDescription("Number of messages processed by the handler."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	e2eLatency, err := meter.Float64Histogram(
+		"pm.message.end_to_end_latency",
+		metric.WithDescription("Duration from the publish time to the start of handling."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := meter.Int64UpDownCounter(
+		"pm.message.in_flight",
+		metric.WithDescription("Number of messages being handled."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &subscriptionMetrics{
+		duration:   duration,
+		processed:  processed,
+		e2eLatency: e2eLatency,
+		inFlight:   inFlight,
+	}, nil
+}
+
+// MetricsSubscriptionInterceptor returns a subscription interceptor that records the handling duration,
+// the number of processed messages by outcome, the end-to-end latency from the publish time and the in-flight messages.
+// The metrics are labelled with the subscription ID.
+// Panics are recorded with the panic outcome and re-panicked, so that a recovery interceptor must be placed outside.
+func MetricsSubscriptionInterceptor(opt ...Option) pm.SubscriptionInterceptor {
+	opts := newOptions(opt)
+	metrics, err := newSubscriptionMetrics(opts.meterProvider.Meter(instrumentationName))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		if metrics == nil {
+			return next
+		}
+		attrs := metric.WithAttributes(
+			semconv.MessagingSystemGCPPubSub,
+			semconv.MessagingDestinationSubscriptionName(info.SubscriptionID),
+		)
+		return func(ctx context.Context, m *pubsub.Message) (err error) {
+			startTime := time.Now()
+			if !m.PublishTime.IsZero() {
+				metrics.e2eLatency.Record(ctx, startTime.Sub(m.PublishTime).Seconds(), attrs)
+			}
+			metrics.inFlight.Add(ctx, 1, attrs)
+
+			outcome := OutcomePanic
+			defer func() {
+				metrics.inFlight.Add(ctx, -1, attrs)
+				metrics.duration.Record(ctx, time.Since(startTime).Seconds(), attrs)
+				metrics.processed.Add(ctx, 1, attrs, metric.WithAttributes(outcomeKey.String(string(outcome))))
+			}()
+
+			err = next(ctx, m)
+			if err != nil {
+				outcome = OutcomeError
+			} else {
+				outcome = OutcomeSuccess
+			}
+			return err
+		}
+	}
+}
+
+type publishMetrics struct {
+	duration metric.Float64Histogram
+	sent     metric.Int64Counter
+	failures metric.Int64Counter
+}
+
+func newPublishMetrics(meter metric.Meter) (*publishMetrics, error) {
+	duration, err := meter.Float64Histogram(
+		"messaging.client.operation.duration",
+		metric.WithDescription("Duration of messaging operation initiated by a producer."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := meter.Int64Counter(
+		"messaging.client.sent.messages",
+		metric.WithDescription("Number of messages producer attempted to send to the broker."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	failures, err := meter.Int64Counter(
+		"pm.publish.failures",
+		metric.WithDescription("Number of messages which failed to be published."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &publishMetrics{
+		duration: duration,
+		sent:     sent,
+		failures: failures,
+	}, nil
+}
+
+// MetricsPublishInterceptor returns a publish interceptor that records the publish latency until the PublishResult is ready,
+// and the number of sent and failed messages.
+// The metrics are labelled with the topic ID.
+func MetricsPublishInterceptor(opt ...Option) pm.PublishInterceptor {
+	opts := newOptions(opt)
+	metrics, err := newPublishMetrics(opts.meterProvider.Meter(instrumentationName))
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		if metrics == nil {
+			return next
+		}
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			attrs := metric.WithAttributes(
+				semconv.MessagingSystemGCPPubSub,
+				semconv.MessagingOperationTypeSend,
+				semconv.MessagingOperationName("send"),
+				semconv.MessagingDestinationName(publisher.ID()),
+			)
+			startTime := time.Now()
+			metrics.sent.Add(ctx, 1, attrs)
+
+			result := next(ctx, publisher, m)
+			go func() {
+				<-result.Ready()
+				metrics.duration.Record(ctx, time.Since(startTime).Seconds(), attrs)
+				if _, err := result.Get(ctx); err != nil {
+					metrics.failures.Add(ctx, 1, attrs)
+				}
+			}()
+			return result
+		}
+	}
+}
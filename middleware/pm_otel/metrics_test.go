@@ -0,0 +1,142 @@
+package pm_otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/zero-color/pm"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
+
+func TestMetricsSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	h := MetricsSubscriptionInterceptor(WithMeterProvider(mp))(&pm.SubscriptionInfo{SubscriptionID: "test-sub"}, func(ctx context.Context, m *pubsub.Message) error {
+		switch string(m.Data) {
+		case "error":
+			return errors.New("error")
+		case "panic":
+			panic("panic")
+		}
+		return nil
+	})
+
+	publishTime := time.Now().Add(-1 * time.Second)
+	for _, data := range []string{"success", "success", "error", "panic"} {
+		func() {
+			defer func() { _ = recover() }()
+			_ = h(context.Background(), &pubsub.Message{Data: []byte(data), PublishTime: publishTime})
+		}()
+	}
+
+	metrics := collectMetrics(t, reader)
+
+	processed, ok := metrics["pm.message.processed"].Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("pm.message.processed is expected to be recorded, got: %v", metrics)
+	}
+	wantProcessed := map[string]int64{"success": 2, "error": 1, "panic": 1}
+	for _, dp := range processed.DataPoints {
+		outcome, _ := dp.Attributes.Value(outcomeKey)
+		if dp.Value != wantProcessed[outcome.AsString()] {
+			t.Errorf("pm.message.processed with outcome %v got: %v, want: %v", outcome.AsString(), dp.Value, wantProcessed[outcome.AsString()])
+		}
+		if sub, _ := dp.Attributes.Value(attribute.Key("messaging.destination.subscription.name")); sub.AsString() != "test-sub" {
+			t.Errorf("pm.message.processed is expected to be labelled with the subscription id, got: %v", sub.AsString())
+		}
+	}
+	if len(processed.DataPoints) != 3 {
+		t.Errorf("pm.message.processed is expected to have 3 outcomes, got: %v", len(processed.DataPoints))
+	}
+
+	duration, ok := metrics["messaging.process.duration"].Data.(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 4 {
+		t.Errorf("messaging.process.duration is expected to be recorded 4 times, got: %v", metrics["messaging.process.duration"].Data)
+	}
+	latency, ok := metrics["pm.message.end_to_end_latency"].Data.(metricdata.Histogram[float64])
+	if !ok || len(latency.DataPoints) != 1 || latency.DataPoints[0].Sum < 4 {
+		t.Errorf("pm.message.end_to_end_latency is expected to be recorded from the publish time, got: %v", metrics["pm.message.end_to_end_latency"].Data)
+	}
+	inFlight, ok := metrics["pm.message.in_flight"].Data.(metricdata.Sum[int64])
+	if !ok || len(inFlight.DataPoints) != 1 || inFlight.DataPoints[0].Value != 0 {
+		t.Errorf("pm.message.in_flight is expected to be 0 after handling, got: %v", metrics["pm.message.in_flight"].Data)
+	}
+}
+
+func TestMetricsPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	defer ts.Close()
+
+	topicPb, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{
+		Name: fmt.Sprintf("projects/test-project/topics/TestMetricsPublishInterceptor_%d", time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := ts.Client.Publisher(topicPb.Name)
+	defer publisher.Stop()
+	missingPublisher := ts.Client.Publisher("projects/test-project/topics/missing")
+	defer missingPublisher.Stop()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(MetricsPublishInterceptor(WithMeterProvider(mp))))
+
+	if _, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test")}).Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pmPublisher.Publish(ctx, missingPublisher, &pubsub.Message{Data: []byte("test")}).Get(ctx); err == nil {
+		t.Fatal("Publish() to the missing topic is expected to return err")
+	}
+
+	// the metrics are recorded asynchronously when the result is ready
+	var metrics map[string]metricdata.Metrics
+	for i := 0; i < 100; i++ {
+		metrics = collectMetrics(t, reader)
+		if d, ok := metrics["messaging.client.operation.duration"].Data.(metricdata.Histogram[float64]); ok && len(d.DataPoints) == 2 {
+			if _, ok := metrics["pm.publish.failures"]; ok {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sent, ok := metrics["messaging.client.sent.messages"].Data.(metricdata.Sum[int64])
+	if !ok || len(sent.DataPoints) != 2 {
+		t.Errorf("messaging.client.sent.messages is expected to be recorded per topic, got: %v", metrics["messaging.client.sent.messages"].Data)
+	}
+	failures, ok := metrics["pm.publish.failures"].Data.(metricdata.Sum[int64])
+	if !ok || len(failures.DataPoints) != 1 || failures.DataPoints[0].Value != 1 {
+		t.Fatalf("pm.publish.failures is expected to be recorded once, got: %v", metrics["pm.publish.failures"].Data)
+	}
+	if topic, _ := failures.DataPoints[0].Attributes.Value(attribute.Key("messaging.destination.name")); topic.AsString() != "missing" {
+		t.Errorf("pm.publish.failures is expected to be labelled with the topic id, got: %v", topic.AsString())
+	}
+}
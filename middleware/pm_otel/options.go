@@ -0,0 +1,54 @@
+package pm_otel
+
+import (
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+	meterProvider  metric.MeterProvider
+}
+
+// Option is a option to change configuration.
+type Option interface {
+	apply(*options)
+}
+
+type OptionFunc struct {
+	f func(*options)
+}
+
+func (s *OptionFunc) apply(so *options) {
+	s.f(so)
+}
+
+func newOptionFunc(f func(*options)) *OptionFunc {
+	return &OptionFunc{
+		f: f,
+	}
+}
+
+// WithTracerProvider customizes the tracer provider. Defaults to the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return newOptionFunc(func(o *options) {
+		o.tracerProvider = tp
+	})
+}
+
+// WithPropagators customizes the propagators to inject and extract the context through the message attributes.
+// Defaults to W3C trace context.
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return newOptionFunc(func(o *options) {
+		o.propagators = p
+	})
+}
+
+// WithMeterProvider customizes the meter provider. Defaults to the global meter provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return newOptionFunc(func(o *options) {
+		o.meterProvider = mp
+	})
+}
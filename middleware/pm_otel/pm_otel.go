@@ -0,0 +1,134 @@
+// Package pm_otel provides OpenTelemetry interceptors for pm.
+//
+// The publish interceptor starts a producer span and injects the trace context into the message attributes,
+// and the subscription interceptor extracts it and starts a consumer span linked to the producer span.
+// The metrics interceptors record the handling and publishing metrics separately from tracing.
+//
+// Example usage:
+//
+//	pubsubPublisher := pm.NewPublisher(
+//		pubsubClient,
+//		pm.WithPublishInterceptor(pm_otel.PublishInterceptor()),
+//	)
+//	pubsubSubscriber := pm.NewSubscriber(
+//		pubsubClient,
+//		pm.WithSubscriptionInterceptor(pm_otel.SubscriptionInterceptor()),
+//	)
+package pm_otel
+
+import (
+	"context"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/zero-color/pm/middleware/pm_otel"
+
+func newOptions(opt []Option) *options {
+	opts := &options{
+		tracerProvider: otel.GetTracerProvider(),
+		propagators:    propagation.TraceContext{},
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, o := range opt {
+		o.apply(opts)
+	}
+	return opts
+}
+
+// PublishInterceptor returns a publish interceptor that starts a producer span and injects the trace context
+// into the message attributes. The span ends when the PublishResult is ready.
+func PublishInterceptor(opt ...Option) pm.PublishInterceptor {
+	opts := newOptions(opt)
+	tracer := opts.tracerProvider.Tracer(instrumentationName)
+
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+			attrs := []attribute.KeyValue{
+				semconv.MessagingSystemGCPPubSub,
+				semconv.MessagingOperationTypeSend,
+				semconv.MessagingOperationName("send"),
+				semconv.MessagingDestinationName(publisher.ID()),
+				semconv.MessagingMessageBodySize(len(m.Data)),
+			}
+			if m.OrderingKey != "" {
+				attrs = append(attrs, semconv.MessagingGCPPubSubMessageOrderingKey(m.OrderingKey))
+			}
+			ctx, span := tracer.Start(ctx, "send "+publisher.ID(),
+				trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(attrs...),
+			)
+
+			if m.Attributes == nil {
+				m.Attributes = map[string]string{}
+			}
+			opts.propagators.Inject(ctx, attributesCarrier(m.Attributes))
+
+			result := next(ctx, publisher, m)
+			go func() {
+				<-result.Ready()
+				serverID, err := result.Get(ctx)
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				} else {
+					span.SetAttributes(semconv.MessagingMessageID(serverID))
+				}
+				span.End()
+			}()
+			return result
+		}
+	}
+}
+
+// SubscriptionInterceptor returns a subscription interceptor that extracts the trace context from the message attributes
+// and starts a consumer span linked to the producer span.
+func SubscriptionInterceptor(opt ...Option) pm.SubscriptionInterceptor {
+	opts := newOptions(opt)
+	tracer := opts.tracerProvider.Tracer(instrumentationName)
+
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		return func(ctx context.Context, m *pubsub.Message) error {
+			producerCtx := opts.propagators.Extract(ctx, attributesCarrier(m.Attributes))
+			producerSpanCtx := trace.SpanContextFromContext(producerCtx)
+
+			attrs := []attribute.KeyValue{
+				semconv.MessagingSystemGCPPubSub,
+				semconv.MessagingOperationTypeProcess,
+				semconv.MessagingOperationName("process"),
+				semconv.MessagingDestinationSubscriptionName(info.SubscriptionID),
+				semconv.MessagingMessageID(m.ID),
+				semconv.MessagingMessageBodySize(len(m.Data)),
+			}
+			if m.OrderingKey != "" {
+				attrs = append(attrs, semconv.MessagingGCPPubSubMessageOrderingKey(m.OrderingKey))
+			}
+			if m.DeliveryAttempt != nil {
+				attrs = append(attrs, semconv.MessagingGCPPubSubMessageDeliveryAttempt(*m.DeliveryAttempt))
+			}
+			startOpts := []trace.SpanStartOption{
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(attrs...),
+			}
+			if producerSpanCtx.IsValid() {
+				startOpts = append(startOpts, trace.WithLinks(trace.Link{SpanContext: producerSpanCtx}))
+			}
+			ctx, span := tracer.Start(producerCtx, "process "+info.SubscriptionID, startOpts...)
+			defer span.End()
+
+			err := next(ctx, m)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pm_otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/zero-color/pm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestInterceptors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	defer ts.Close()
+
+	topicPb, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{
+		Name: fmt.Sprintf("projects/test-project/topics/TestInterceptors_%d", time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := ts.Client.Publisher(topicPb.Name)
+	defer publisher.Stop()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(PublishInterceptor(WithTracerProvider(tp))))
+	m := &pubsub.Message{Data: []byte("test"), OrderingKey: "key"}
+	publisher.EnableMessageOrdering = true
+	serverID, err := pmPublisher.Publish(ctx, publisher, m).Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Attributes["traceparent"]; !ok {
+		t.Fatalf("traceparent is expected to be injected into the attributes, got: %v", m.Attributes)
+	}
+
+	deliveryAttempt := 2
+	received := &pubsub.Message{ID: serverID, Data: m.Data, Attributes: m.Attributes, OrderingKey: m.OrderingKey, DeliveryAttempt: &deliveryAttempt}
+	interceptor := SubscriptionInterceptor(WithTracerProvider(tp))
+	err = interceptor(&pm.SubscriptionInfo{SubscriptionID: "test-sub"}, func(ctx context.Context, m *pubsub.Message) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("The handler context is expected to have the consumer span")
+		}
+		return errors.New("error")
+	})(ctx, received)
+	if err == nil {
+		t.Fatal("The handler error is expected to be returned")
+	}
+
+	// the producer span ends asynchronously when the result is ready
+	var spans tracetest.SpanStubs
+	for i := 0; i < 100; i++ {
+		if spans = exporter.GetSpans(); len(spans) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("2 spans are expected to be exported, got: %v", len(spans))
+	}
+	var producer, consumer tracetest.SpanStub
+	for _, s := range spans {
+		switch s.SpanKind {
+		case trace.SpanKindProducer:
+			producer = s
+		case trace.SpanKindConsumer:
+			consumer = s
+		}
+	}
+
+	if want := "send " + publisher.ID(); producer.Name != want {
+		t.Errorf("producer span name got: %v, want: %v", producer.Name, want)
+	}
+	if v, _ := spanAttribute(producer, "messaging.destination.name"); v.AsString() != publisher.ID() {
+		t.Errorf("messaging.destination.name got: %v, want: %v", v.AsString(), publisher.ID())
+	}
+	if v, _ := spanAttribute(producer, "messaging.message.id"); v.AsString() != serverID {
+		t.Errorf("messaging.message.id got: %v, want: %v", v.AsString(), serverID)
+	}
+	if v, _ := spanAttribute(producer, "messaging.gcp_pubsub.message.ordering_key"); v.AsString() != "key" {
+		t.Errorf("messaging.gcp_pubsub.message.ordering_key got: %v, want: %v", v.AsString(), "key")
+	}
+
+	if consumer.Name != "process test-sub" {
+		t.Errorf("consumer span name got: %v, want: %v", consumer.Name, "process test-sub")
+	}
+	if consumer.Parent.SpanID() != producer.SpanContext.SpanID() {
+		t.Errorf("consumer span is expected to be the child of the producer span")
+	}
+	if len(consumer.Links) != 1 || consumer.Links[0].SpanContext.SpanID() != producer.SpanContext.SpanID() {
+		t.Errorf("consumer span is expected to be linked to the producer span, got: %v", consumer.Links)
+	}
+	if v, _ := spanAttribute(consumer, "messaging.gcp_pubsub.message.delivery_attempt"); v.AsInt64() != 2 {
+		t.Errorf("messaging.gcp_pubsub.message.delivery_attempt got: %v, want: %v", v.AsInt64(), 2)
+	}
+	if consumer.Status.Code != codes.Error {
+		t.Errorf("consumer span status got: %v, want: %v", consumer.Status.Code, codes.Error)
+	}
+}
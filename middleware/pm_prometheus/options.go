@@ -0,0 +1,51 @@
+package pm_prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type options struct {
+	registerer             prometheus.Registerer
+	handlingTimeBuckets    []float64
+	deliveryAttemptBuckets []float64
+	messageAgeBuckets      []float64
+	publishTimeBuckets     []float64
+}
+
+type Option func(*options)
+
+// WithRegisterer customizes the registerer which the collectors are registered to.
+// Defaults to prometheus.DefaultRegisterer.
+func WithRegisterer(r prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registerer = r
+	}
+}
+
+// WithHandlingTimeBuckets customizes the buckets of the handling time histogram.
+func WithHandlingTimeBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.handlingTimeBuckets = buckets
+	}
+}
+
+// WithDeliveryAttemptBuckets customizes the buckets of the delivery attempt histogram.
+func WithDeliveryAttemptBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.deliveryAttemptBuckets = buckets
+	}
+}
+
+// WithMessageAgeBuckets customizes the buckets of the message age histogram.
+func WithMessageAgeBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.messageAgeBuckets = buckets
+	}
+}
+
+// WithPublishTimeBuckets customizes the buckets of the publish time histogram.
+func WithPublishTimeBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.publishTimeBuckets = buckets
+	}
+}
@@ -0,0 +1,160 @@
+// Package pm_prometheus provides Prometheus metrics interceptors for pm.
+//
+// Example usage:
+//
+//	metrics := pm_prometheus.NewMetrics()
+//	pubsubPublisher := pm.NewPublisher(
+//		pubsubClient,
+//		pm.WithPublishInterceptor(metrics.PublishInterceptor()),
+//	)
+//	pubsubSubscriber := pm.NewSubscriber(
+//		pubsubClient,
+//		pm.WithSubscriptionInterceptor(metrics.SubscriptionInterceptor()),
+//	)
+package pm_prometheus
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zero-color/pm"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+	outcomePanic   = "panic"
+)
+
+// Metrics represents a collection of metrics to be registered on a Prometheus metrics registry for pm.
+type Metrics struct {
+	subscriptionHandled          *prometheus.CounterVec
+	subscriptionHandlingSeconds  *prometheus.HistogramVec
+	subscriptionDeliveryAttempts *prometheus.HistogramVec
+	subscriptionMessageAge       *prometheus.HistogramVec
+	publishTotal                 *prometheus.CounterVec
+	publishSeconds               *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// NewMetrics initializes Metrics and registers it to the registerer.
+// It panics when the metrics are already registered, so that it should be called once per registerer.
+func NewMetrics(opt ...Option) *Metrics {
+	opts := options{
+		registerer:             prometheus.DefaultRegisterer,
+		handlingTimeBuckets:    prometheus.DefBuckets,
+		deliveryAttemptBuckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		messageAgeBuckets:      []float64{0.01, 0.1, 1, 10, 60, 300, 1800, 3600, 21600, 86400},
+		publishTimeBuckets:     prometheus.DefBuckets,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+
+	m := &Metrics{
+		subscriptionHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pm_subscription_handled_total",
+			Help: "Total number of messages handled by the subscription, regardless of outcome.",
+		}, []string{"subscription", "outcome"}),
+		subscriptionHandlingSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pm_subscription_handling_seconds",
+			Help:    "Histogram of the time (seconds) taken to handle a message.",
+			Buckets: opts.handlingTimeBuckets,
+		}, []string{"subscription"}),
+		subscriptionDeliveryAttempts: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pm_subscription_delivery_attempts",
+			Help:    "Histogram of the delivery attempts of handled messages. Only recorded when dead lettering is enabled.",
+			Buckets: opts.deliveryAttemptBuckets,
+		}, []string{"subscription"}),
+		subscriptionMessageAge: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pm_subscription_message_age_seconds",
+			Help:    "Histogram of the time (seconds) from the publish time to the start of handling.",
+			Buckets: opts.messageAgeBuckets,
+		}, []string{"subscription"}),
+		publishTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pm_publish_total",
+			Help: "Total number of published messages, regardless of outcome.",
+		}, []string{"topic", "outcome"}),
+		publishSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pm_publish_seconds",
+			Help:    "Histogram of the time (seconds) until the publish result is ready.",
+			Buckets: opts.publishTimeBuckets,
+		}, []string{"topic"}),
+	}
+	opts.registerer.MustRegister(m)
+	return m
+}
+
+// Describe sends the super-set of all possible descriptors of metrics collected by this Collector
+// to the provided channel and returns once the last descriptor has been sent.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.subscriptionHandled.Describe(ch)
+	m.subscriptionHandlingSeconds.Describe(ch)
+	m.subscriptionDeliveryAttempts.Describe(ch)
+	m.subscriptionMessageAge.Describe(ch)
+	m.publishTotal.Describe(ch)
+	m.publishSeconds.Describe(ch)
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.subscriptionHandled.Collect(ch)
+	m.subscriptionHandlingSeconds.Collect(ch)
+	m.subscriptionDeliveryAttempts.Collect(ch)
+	m.subscriptionMessageAge.Collect(ch)
+	m.publishTotal.Collect(ch)
+	m.publishSeconds.Collect(ch)
+}
+
+// SubscriptionInterceptor returns a subscription interceptor that records the handling metrics.
+// Panics are recorded with the panic outcome and re-panicked, so that a recovery interceptor must be placed outside.
+func (m *Metrics) SubscriptionInterceptor() pm.SubscriptionInterceptor {
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		return func(ctx context.Context, msg *pubsub.Message) error {
+			startTime := time.Now()
+			if !msg.PublishTime.IsZero() {
+				m.subscriptionMessageAge.WithLabelValues(info.SubscriptionID).Observe(startTime.Sub(msg.PublishTime).Seconds())
+			}
+			if msg.DeliveryAttempt != nil {
+				m.subscriptionDeliveryAttempts.WithLabelValues(info.SubscriptionID).Observe(float64(*msg.DeliveryAttempt))
+			}
+
+			outcome := outcomePanic
+			defer func() {
+				m.subscriptionHandlingSeconds.WithLabelValues(info.SubscriptionID).Observe(time.Since(startTime).Seconds())
+				m.subscriptionHandled.WithLabelValues(info.SubscriptionID, outcome).Inc()
+			}()
+
+			err := next(ctx, msg)
+			if err != nil {
+				outcome = outcomeError
+			} else {
+				outcome = outcomeSuccess
+			}
+			return err
+		}
+	}
+}
+
+// PublishInterceptor returns a publish interceptor that records the publishing metrics when the PublishResult is ready.
+func (m *Metrics) PublishInterceptor() pm.PublishInterceptor {
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, publisher *pubsub.Publisher, msg *pubsub.Message) pm.PublishResult {
+			startTime := time.Now()
+			result := next(ctx, publisher, msg)
+			go func() {
+				<-result.Ready()
+				m.publishSeconds.WithLabelValues(publisher.ID()).Observe(time.Since(startTime).Seconds())
+				outcome := outcomeSuccess
+				if _, err := result.Get(ctx); err != nil {
+					outcome = outcomeError
+				}
+				m.publishTotal.WithLabelValues(publisher.ID(), outcome).Inc()
+			}()
+			return result
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package pm_prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/zero-color/pm"
+)
+
+func TestMetrics_SubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics(WithRegisterer(prometheus.NewRegistry()))
+	h := metrics.SubscriptionInterceptor()(&pm.SubscriptionInfo{SubscriptionID: "test-sub"}, func(ctx context.Context, m *pubsub.Message) error {
+		switch string(m.Data) {
+		case "error":
+			return errors.New("error")
+		case "panic":
+			panic("panic")
+		}
+		return nil
+	})
+
+	deliveryAttempt := 3
+	for _, data := range []string{"success", "success", "error", "panic"} {
+		func() {
+			defer func() { _ = recover() }()
+			_ = h(context.Background(), &pubsub.Message{Data: []byte(data), PublishTime: time.Now().Add(-1 * time.Minute), DeliveryAttempt: &deliveryAttempt})
+		}()
+	}
+
+	for outcome, want := range map[string]float64{"success": 2, "error": 1, "panic": 1} {
+		if got := testutil.ToFloat64(metrics.subscriptionHandled.WithLabelValues("test-sub", outcome)); got != want {
+			t.Errorf("pm_subscription_handled_total with outcome %v got: %v, want: %v", outcome, got, want)
+		}
+	}
+	if got := testutil.CollectAndCount(metrics.subscriptionHandlingSeconds, "pm_subscription_handling_seconds"); got != 1 {
+		t.Errorf("pm_subscription_handling_seconds is expected to be recorded for the subscription, got: %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.subscriptionDeliveryAttempts, "pm_subscription_delivery_attempts"); got != 1 {
+		t.Errorf("pm_subscription_delivery_attempts is expected to be recorded for the subscription, got: %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.subscriptionMessageAge, "pm_subscription_message_age_seconds"); got != 1 {
+		t.Errorf("pm_subscription_message_age_seconds is expected to be recorded for the subscription, got: %v", got)
+	}
+}
+
+func TestMetrics_PublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	defer ts.Close()
+
+	topicPb, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{
+		Name: fmt.Sprintf("projects/test-project/topics/TestMetrics_PublishInterceptor_%d", time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := ts.Client.Publisher(topicPb.Name)
+	defer publisher.Stop()
+
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(WithRegisterer(registry), WithPublishTimeBuckets([]float64{0.1, 1}))
+	pmPublisher := pm.NewPublisher(ts.Client, pm.WithPublishInterceptor(metrics.PublishInterceptor()))
+	if _, err := pmPublisher.Publish(ctx, publisher, &pubsub.Message{Data: []byte("test")}).Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the metrics are recorded asynchronously when the result is ready
+	counter := metrics.publishTotal.WithLabelValues(publisher.ID(), "success")
+	for i := 0; i < 100 && testutil.ToFloat64(counter) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := testutil.ToFloat64(counter); got != 1 {
+		t.Errorf("pm_publish_total got: %v, want: %v", got, 1)
+	}
+	if got, err := testutil.GatherAndCount(registry, "pm_publish_seconds"); err != nil || got != 1 {
+		t.Errorf("pm_publish_seconds is expected to be registered and recorded, got: %v, err: %v", got, err)
+	}
+}
@@ -0,0 +1,78 @@
+package pm_quarantine
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryStrike struct {
+	count     int
+	expiresAt time.Time
+}
+
+// memorySweepInterval is how often the expired strikes of the other keys are deleted.
+const memorySweepInterval = 1 * time.Minute
+
+type memoryStrikeStore struct {
+	mu        sync.Mutex
+	strikes   map[string]*memoryStrike
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStrikeStore initializes a StrikeStore which holds the strikes in memory.
+// The strikes are counted per process.
+func NewMemoryStrikeStore() StrikeStore {
+	return &memoryStrikeStore{
+		strikes: map[string]*memoryStrike{},
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStrikeStore) Strike(_ context.Context, key string, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		s.deleteExpired(now)
+		s.lastSweep = now
+	}
+	strike, ok := s.strikes[key]
+	if !ok || !now.Before(strike.expiresAt) {
+		strike = &memoryStrike{}
+		s.strikes[key] = strike
+	}
+	strike.count++
+	strike.expiresAt = now.Add(ttl)
+	return strike.count, nil
+}
+
+func (s *memoryStrikeStore) Strikes(_ context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	strike, ok := s.strikes[key]
+	if !ok {
+		return 0, nil
+	}
+	if !s.now().Before(strike.expiresAt) {
+		delete(s.strikes, key)
+		return 0, nil
+	}
+	return strike.count, nil
+}
+
+func (s *memoryStrikeStore) Clear(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.strikes, key)
+	return nil
+}
+
+func (s *memoryStrikeStore) deleteExpired(now time.Time) {
+	for key, strike := range s.strikes {
+		if !now.Before(strike.expiresAt) {
+			delete(s.strikes, key)
+		}
+	}
+}
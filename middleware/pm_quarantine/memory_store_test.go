@@ -0,0 +1,52 @@
+package pm_quarantine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_memoryStrikeStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStrikeStore().(*memoryStrikeStore)
+	store.now = func() time.Time { return now }
+
+	for i := 1; i <= 2; i++ {
+		got, err := store.Strike(ctx, "key", time.Minute)
+		if err != nil {
+			t.Fatalf("memoryStrikeStore.Strike is expected to return nil, but got err: %v", err)
+		}
+		if got != i {
+			t.Errorf("memoryStrikeStore.Strike got: %v, want: %v", got, i)
+		}
+	}
+	if got, _ := store.Strikes(ctx, "key"); got != 2 {
+		t.Errorf("memoryStrikeStore.Strikes got: %v, want: %v", got, 2)
+	}
+
+	now = now.Add(time.Minute)
+	if got, _ := store.Strikes(ctx, "key"); got != 0 {
+		t.Errorf("The strikes are expected to expire after the TTL, got: %v", got)
+	}
+
+	if got, _ := store.Strike(ctx, "key", time.Minute); got != 1 {
+		t.Errorf("The strike after the expiry is expected to count from 1, got: %v", got)
+	}
+
+	_, _ = store.Strike(ctx, "other", time.Second)
+	now = now.Add(memorySweepInterval)
+	_, _ = store.Strike(ctx, "key", time.Minute)
+	if _, ok := store.strikes["other"]; ok {
+		t.Error("The expired strikes of the other keys are expected to be swept")
+	}
+
+	if err := store.Clear(ctx, "key"); err != nil {
+		t.Fatalf("memoryStrikeStore.Clear is expected to return nil, but got err: %v", err)
+	}
+	if got, _ := store.Strikes(ctx, "key"); got != 0 {
+		t.Errorf("The strikes are expected to be cleared, got: %v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package pm_quarantine
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// KeyFunc returns the key which the strikes are counted by.
+type KeyFunc func(info *pm.SubscriptionInfo, m *pubsub.Message) string
+
+// MessageIDKey is the KeyFunc which counts the strikes per message ID in each subscription.
+func MessageIDKey(info *pm.SubscriptionInfo, m *pubsub.Message) string {
+	return info.SubscriptionID + ":" + m.ID
+}
+
+// AttributeKey returns the KeyFunc which counts the strikes per value of the given attribute such as a de-duplicate key
+// in each subscription. When the attribute is not set, the strikes are counted per message ID.
+func AttributeKey(attribute string) KeyFunc {
+	return func(info *pm.SubscriptionInfo, m *pubsub.Message) string {
+		if v, ok := m.Attributes[attribute]; ok {
+			return info.SubscriptionID + ":" + v
+		}
+		return MessageIDKey(info, m)
+	}
+}
+
+// QuarantineFunc handles the message which reached the max strikes instead of the handler.
+// The message is acked when it returns nil, otherwise it's nacked.
+type QuarantineFunc func(ctx context.Context, info *pm.SubscriptionInfo, m *pubsub.Message, strikes int) error
+
+func defaultQuarantineFunc(_ context.Context, info *pm.SubscriptionInfo, m *pubsub.Message, strikes int) error {
+	log.Printf("pm_quarantine: message '%s' of subscription '%s' is quarantined after %d strikes\n", m.ID, info.SubscriptionID, strikes)
+	return nil
+}
+
+type options struct {
+	keyFunc        KeyFunc
+	quarantineFunc QuarantineFunc
+	failureDecider func(err error) bool
+	ttl            time.Duration
+}
+
+type Option func(*options)
+
+// WithKeyFunc customizes the function for deciding the key which the strikes are counted by.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = f
+	}
+}
+
+// WithQuarantineFunc customizes the function for handling the quarantined message.
+// By default, the message is only logged and acked.
+func WithQuarantineFunc(f QuarantineFunc) Option {
+	return func(o *options) {
+		o.quarantineFunc = f
+	}
+}
+
+// WithQuarantineTopic publishes the quarantined message to the topic before it's acked.
+func WithQuarantineTopic(publisher *pm.Publisher, topic *pubsub.Publisher) Option {
+	return WithQuarantineFunc(PublishTo(publisher, topic))
+}
+
+// WithFailureDecider customizes the function for deciding if the returned error is a strike.
+// By default, all the errors are strikes. Panics are always strikes.
+func WithFailureDecider(f func(err error) bool) Option {
+	return func(o *options) {
+		o.failureDecider = f
+	}
+}
+
+// WithTTL customizes how long the strikes of a key are kept since the last strike.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
@@ -0,0 +1,141 @@
+// Package pm_quarantine provides a subscription interceptor which quarantines poison messages,
+// whose handlers panicked or failed repeatedly, even on subscriptions without a dead-letter policy.
+package pm_quarantine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+const (
+	// SubscriptionAttribute is the attribute of the quarantined message published by PublishTo,
+	// which holds the subscription ID the message was quarantined from.
+	SubscriptionAttribute = "pm_quarantine_subscription"
+	// MessageIDAttribute holds the original message ID of the quarantined message.
+	MessageIDAttribute = "pm_quarantine_message_id"
+	// StrikesAttribute holds the number of strikes of the quarantined message.
+	StrikesAttribute = "pm_quarantine_strikes"
+
+	defaultTTL = 24 * time.Hour
+)
+
+// StrikeStore counts the strikes of keys.
+type StrikeStore interface {
+	// Strike increments the strikes of the key and returns the new count.
+	// The strikes expire when no strike is added within the TTL.
+	Strike(ctx context.Context, key string, ttl time.Duration) (int, error)
+	// Strikes returns the current count of the key.
+	Strikes(ctx context.Context, key string) (int, error)
+	// Clear removes the strikes of the key.
+	Clear(ctx context.Context, key string) error
+}
+
+// SubscriptionInterceptor counts the panics and the errors of the handler as strikes of the message.
+// Once the message has maxStrikes strikes, the handler is no longer called for the message,
+// and the message is handed to the quarantine function and acked.
+// A panic is counted and then re-panicked, so it should be placed inside pm_recovery.
+//
+// // subscriber
+// pubsubSubscriber := pm.NewSubscriber(
+//
+//		pubsubClient,
+//		pm.WithSubscriptionInterceptor(
+//			pm_recovery.SubscriptionInterceptor(),
+//			pm_quarantine.SubscriptionInterceptor(
+//				pm_quarantine.NewMemoryStrikeStore(),
+//				3,
+//				pm_quarantine.WithQuarantineTopic(pubsubPublisher, pubsubClient.Publisher("quarantine-topic")),
+//			),
+//		),
+//	)
+//
+// It panics when maxStrikes or the TTL isn't positive.
+func SubscriptionInterceptor(store StrikeStore, maxStrikes int, opt ...Option) pm.SubscriptionInterceptor {
+	opts := options{
+		keyFunc:        MessageIDKey,
+		quarantineFunc: defaultQuarantineFunc,
+		failureDecider: func(err error) bool { return true },
+		ttl:            defaultTTL,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if maxStrikes <= 0 {
+		panic(fmt.Sprintf("pm_quarantine: maxStrikes must be positive, got: %d", maxStrikes))
+	}
+	if opts.ttl <= 0 {
+		panic(fmt.Sprintf("pm_quarantine: TTL must be positive, got: %v", opts.ttl))
+	}
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		return func(ctx context.Context, m *pubsub.Message) error {
+			key := opts.keyFunc(info, m)
+			strikes, err := store.Strikes(ctx, key)
+			if err != nil {
+				m.Nack()
+				return err
+			}
+			if strikes >= maxStrikes {
+				if err := opts.quarantineFunc(ctx, info, m, strikes); err != nil {
+					m.Nack()
+					return err
+				}
+				m.Ack()
+				return nil
+			}
+
+			err = func() error {
+				defer func() {
+					if r := recover(); r != nil {
+						_, _ = store.Strike(ctx, key, opts.ttl)
+						panic(r)
+					}
+				}()
+				return next(ctx, m)
+			}()
+
+			if err != nil {
+				if opts.failureDecider(err) {
+					if _, strikeErr := store.Strike(ctx, key, opts.ttl); strikeErr != nil {
+						return errors.Join(err, strikeErr)
+					}
+				}
+				return err
+			}
+			if strikes > 0 {
+				_ = store.Clear(ctx, key)
+			}
+			return nil
+		}
+	}
+}
+
+// PublishTo returns the QuarantineFunc which publishes the quarantined message to the topic.
+// The subscription ID, the original message ID and the strikes are added to the attributes.
+// The ordering key is kept when message ordering is enabled for the topic.
+func PublishTo(publisher *pm.Publisher, topic *pubsub.Publisher) QuarantineFunc {
+	return func(ctx context.Context, info *pm.SubscriptionInfo, m *pubsub.Message, strikes int) error {
+		attributes := make(map[string]string, len(m.Attributes)+3)
+		for k, v := range m.Attributes {
+			attributes[k] = v
+		}
+		attributes[SubscriptionAttribute] = info.SubscriptionID
+		attributes[MessageIDAttribute] = m.ID
+		attributes[StrikesAttribute] = strconv.Itoa(strikes)
+
+		msg := &pubsub.Message{
+			Data:       m.Data,
+			Attributes: attributes,
+		}
+		if topic.EnableMessageOrdering {
+			msg.OrderingKey = m.OrderingKey
+		}
+		_, err := publisher.Publish(ctx, topic, msg).Get(ctx)
+		return err
+	}
+}
@@ -0,0 +1,218 @@
+package pm_quarantine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/zero-color/pm"
+)
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	info := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+
+	t.Run("quarantines the message after max strikes of errors", func(t *testing.T) {
+		t.Parallel()
+
+		var called, quarantined int
+		interceptor := SubscriptionInterceptor(NewMemoryStrikeStore(), 2, WithQuarantineFunc(func(ctx context.Context, info *pm.SubscriptionInfo, m *pubsub.Message, strikes int) error {
+			quarantined = strikes
+			return nil
+		}))
+		handler := interceptor(info, func(ctx context.Context, m *pubsub.Message) error {
+			called++
+			return errors.New("error")
+		})
+
+		for i := 0; i < 3; i++ {
+			err := handler(context.Background(), &pubsub.Message{ID: "message-id"})
+			if i < 2 && err == nil {
+				t.Errorf("The handler error is expected to be returned before quarantine")
+			}
+			if i == 2 && err != nil {
+				t.Errorf("SubscriptionInterceptor is expected to return nil after quarantine, but got err: %v", err)
+			}
+		}
+		if called != 2 {
+			t.Errorf("The handler is expected to be called until max strikes, got: %v, want: %v", called, 2)
+		}
+		if quarantined != 2 {
+			t.Errorf("The message is expected to be quarantined with 2 strikes, got: %v", quarantined)
+		}
+	})
+
+	t.Run("counts panics as strikes and re-panics", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewMemoryStrikeStore()
+		interceptor := SubscriptionInterceptor(store, 3)
+		handler := interceptor(info, func(ctx context.Context, m *pubsub.Message) error {
+			panic("panic")
+		})
+
+		func() {
+			defer func() {
+				if r := recover(); r != "panic" {
+					t.Errorf("The panic is expected to be re-panicked, got: %v", r)
+				}
+			}()
+			_ = handler(context.Background(), &pubsub.Message{ID: "message-id"})
+		}()
+
+		strikes, _ := store.Strikes(context.Background(), MessageIDKey(info, &pubsub.Message{ID: "message-id"}))
+		if strikes != 1 {
+			t.Errorf("The panic is expected to be counted as a strike, got: %v, want: %v", strikes, 1)
+		}
+	})
+
+	t.Run("doesn't count errors which are not failures", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewMemoryStrikeStore()
+		interceptor := SubscriptionInterceptor(store, 1, WithFailureDecider(func(err error) bool { return false }))
+		handler := interceptor(info, func(ctx context.Context, m *pubsub.Message) error {
+			return errors.New("error")
+		})
+		_ = handler(context.Background(), &pubsub.Message{ID: "message-id"})
+
+		strikes, _ := store.Strikes(context.Background(), MessageIDKey(info, &pubsub.Message{ID: "message-id"}))
+		if strikes != 0 {
+			t.Errorf("The error is not expected to be counted as a strike, got: %v", strikes)
+		}
+	})
+
+	t.Run("clears the strikes when the handler succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		store := NewMemoryStrikeStore()
+		fail := true
+		interceptor := SubscriptionInterceptor(store, 3, WithKeyFunc(AttributeKey("dedup_key")))
+		handler := interceptor(info, func(ctx context.Context, m *pubsub.Message) error {
+			if fail {
+				return errors.New("error")
+			}
+			return nil
+		})
+		m := &pubsub.Message{ID: "message-id", Attributes: map[string]string{"dedup_key": "key"}}
+		_ = handler(context.Background(), m)
+		fail = false
+		if err := handler(context.Background(), m); err != nil {
+			t.Fatalf("SubscriptionInterceptor is expected to return nil, but got err: %v", err)
+		}
+
+		strikes, _ := store.Strikes(context.Background(), "test-sub:key")
+		if strikes != 0 {
+			t.Errorf("The strikes are expected to be cleared, got: %v", strikes)
+		}
+	})
+
+	t.Run("returns the error of the quarantine function", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("quarantine error")
+		interceptor := SubscriptionInterceptor(NewMemoryStrikeStore(), 1, WithQuarantineFunc(func(ctx context.Context, info *pm.SubscriptionInfo, m *pubsub.Message, strikes int) error {
+			return wantErr
+		}))
+		handler := interceptor(info, func(ctx context.Context, m *pubsub.Message) error {
+			return errors.New("handler error")
+		})
+		m := &pubsub.Message{ID: "message-id"}
+		_ = handler(context.Background(), m)
+		err := handler(context.Background(), m)
+		if !errors.Is(err, wantErr) {
+			t.Errorf("SubscriptionInterceptor is expected to return %v, but got err: %v", wantErr, err)
+		}
+	})
+}
+
+func TestSubscriptionInterceptor_invalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		maxStrikes int
+		opt        []Option
+	}{
+		{name: "zero maxStrikes", maxStrikes: 0},
+		{name: "negative maxStrikes", maxStrikes: -1},
+		{name: "zero TTL", maxStrikes: 1, opt: []Option{WithTTL(0)}},
+		{name: "negative TTL", maxStrikes: 1, opt: []Option{WithTTL(-time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("SubscriptionInterceptor is expected to panic")
+				}
+			}()
+			SubscriptionInterceptor(NewMemoryStrikeStore(), tt.maxStrikes, tt.opt...)
+		})
+	}
+}
+
+func TestPublishTo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ts := pm.NewTestServer(ctx, t)
+	defer ts.Close()
+
+	name := fmt.Sprintf("TestPublishTo_%d", time.Now().UnixNano())
+	topicPb, err := ts.Client.TopicAdminClient.CreateTopic(ctx, &pb.Topic{
+		Name: fmt.Sprintf("projects/test-project/topics/%s", name),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subPb, err := ts.Client.SubscriptionAdminClient.CreateSubscription(ctx, &pb.Subscription{
+		Name:  fmt.Sprintf("projects/test-project/subscriptions/%s", name),
+		Topic: topicPb.Name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topic := ts.Client.Publisher(topicPb.Name)
+	topic.EnableMessageOrdering = true
+	defer topic.Stop()
+	quarantine := PublishTo(pm.NewPublisher(ts.Client), topic)
+	err = quarantine(ctx, &pm.SubscriptionInfo{SubscriptionID: "test-sub"}, &pubsub.Message{
+		ID:          "message-id",
+		Data:        []byte("test"),
+		Attributes:  map[string]string{"type": "created"},
+		OrderingKey: "key",
+	}, 3)
+	if err != nil {
+		t.Fatalf("PublishTo() is expected to return nil, but got err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	var received *pubsub.Message
+	_ = ts.Client.Subscriber(subPb.Name).Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+		m.Ack()
+		received = m
+		cancel()
+	})
+	if received == nil {
+		t.Fatal("The quarantined message is expected to be published")
+	}
+	want := map[string]string{
+		"type":                "created",
+		SubscriptionAttribute: "test-sub",
+		MessageIDAttribute:    "message-id",
+		StrikesAttribute:      "3",
+	}
+	if fmt.Sprint(received.Attributes) != fmt.Sprint(want) {
+		t.Errorf("Attributes of the quarantined message got: %v, want: %v", received.Attributes, want)
+	}
+	if received.OrderingKey != "key" {
+		t.Errorf("Ordering key of the quarantined message got: %v, want: %v", received.OrderingKey, "key")
+	}
+}
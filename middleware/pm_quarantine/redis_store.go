@@ -0,0 +1,52 @@
+package pm_quarantine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type redisStrikeStore struct {
+	redisClient *redis.Client
+	keyPrefix   string
+}
+
+// NewRedisStrikeStore initializes a StrikeStore which shares the strikes through Redis.
+// The strikes are counted across all the processes using the same key prefix.
+func NewRedisStrikeStore(redisClient *redis.Client, keyPrefix string) StrikeStore {
+	return &redisStrikeStore{
+		redisClient: redisClient,
+		keyPrefix:   keyPrefix,
+	}
+}
+
+func (s *redisStrikeStore) Strike(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, s.key(key))
+		pipe.PExpire(ctx, s.key(key), ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *redisStrikeStore) Strikes(ctx context.Context, key string) (int, error) {
+	strikes, err := s.redisClient.Get(ctx, s.key(key)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return strikes, err
+}
+
+func (s *redisStrikeStore) Clear(ctx context.Context, key string) error {
+	return s.redisClient.Del(ctx, s.key(key)).Err()
+}
+
+func (s *redisStrikeStore) key(key string) string {
+	return s.keyPrefix + ":" + key
+}
@@ -0,0 +1,41 @@
+package pm_quarantine
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/xid"
+)
+
+func Test_redisStrikeStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	redisClient := redis.NewClient(&redis.Options{Addr: os.Getenv("REDIS_URL")})
+	store := NewRedisStrikeStore(redisClient, xid.New().String())
+
+	if got, err := store.Strikes(ctx, "key"); err != nil || got != 0 {
+		t.Fatalf("redisStrikeStore.Strikes is expected to return 0 for a new key, got: %v, err: %v", got, err)
+	}
+	for i := 1; i <= 2; i++ {
+		got, err := store.Strike(ctx, "key", time.Minute)
+		if err != nil {
+			t.Fatalf("redisStrikeStore.Strike is expected to return nil, but got err: %v", err)
+		}
+		if got != i {
+			t.Errorf("redisStrikeStore.Strike got: %v, want: %v", got, i)
+		}
+	}
+	if got, _ := store.Strikes(ctx, "key"); got != 2 {
+		t.Errorf("redisStrikeStore.Strikes got: %v, want: %v", got, 2)
+	}
+	if err := store.Clear(ctx, "key"); err != nil {
+		t.Fatalf("redisStrikeStore.Clear is expected to return nil, but got err: %v", err)
+	}
+	if got, _ := store.Strikes(ctx, "key"); got != 0 {
+		t.Errorf("The strikes are expected to be cleared, got: %v", got)
+	}
+}
@@ -0,0 +1,44 @@
+package pm_ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type memoryLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewMemoryLimiter initializes a token bucket Limiter which holds the buckets in memory.
+// The rate limit is applied per process.
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{limiters: make(map[string]*rate.Limiter)}
+}
+
+func (l *memoryLimiter) Take(_ context.Context, key string, limit Limit, maxWait time.Duration) (time.Duration, bool, error) {
+	l.mu.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit.PerSecond), limit.burst())
+		l.limiters[key] = limiter
+	} else if limiter.Limit() != rate.Limit(limit.PerSecond) || limiter.Burst() != limit.burst() {
+		limiter.SetLimit(rate.Limit(limit.PerSecond))
+		limiter.SetBurst(limit.burst())
+	}
+	l.mu.Unlock()
+
+	r := limiter.Reserve()
+	if !r.OK() {
+		return 0, false, nil
+	}
+	delay := r.Delay()
+	if delay > maxWait {
+		r.Cancel()
+		return 0, false, nil
+	}
+	return delay, true, nil
+}
@@ -0,0 +1,53 @@
+package pm_ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_memoryLimiter_Take(t *testing.T) {
+	t.Parallel()
+
+	t.Run("takes tokens within the burst without waiting", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewMemoryLimiter()
+		for i := 0; i < 3; i++ {
+			wait, ok, err := limiter.Take(context.Background(), "test", Limit{PerSecond: 1, Burst: 3}, 0)
+			if err != nil {
+				t.Fatalf("memoryLimiter.Take is expected to return nil, but got err: %v", err)
+			}
+			if !ok || wait != 0 {
+				t.Errorf("memoryLimiter.Take is expected to take a token without waiting, got wait: %v, ok: %v", wait, ok)
+			}
+		}
+	})
+
+	t.Run("doesn't take a token when it's not available within max wait", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewMemoryLimiter()
+		limit := Limit{PerSecond: 1}
+		_, _, _ = limiter.Take(context.Background(), "test", limit, 0)
+
+		if _, ok, _ := limiter.Take(context.Background(), "test", limit, 100*time.Millisecond); ok {
+			t.Error("memoryLimiter.Take must not take a token which is not available within max wait")
+		}
+		wait, ok, _ := limiter.Take(context.Background(), "test", limit, 2*time.Second)
+		if !ok || wait <= 0 {
+			t.Errorf("memoryLimiter.Take is expected to take a token with waiting, got wait: %v, ok: %v", wait, ok)
+		}
+	})
+
+	t.Run("buckets are separated per key", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewMemoryLimiter()
+		limit := Limit{PerSecond: 1}
+		_, _, _ = limiter.Take(context.Background(), "a", limit, 0)
+		if _, ok, _ := limiter.Take(context.Background(), "b", limit, 0); !ok {
+			t.Error("memoryLimiter.Take is expected to take a token of another key")
+		}
+	})
+}
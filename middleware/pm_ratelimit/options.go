@@ -0,0 +1,65 @@
+package pm_ratelimit
+
+import (
+	"math"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// KeyFunc returns the key which the rate limit is applied to.
+type KeyFunc func(info *pm.SubscriptionInfo, m *pubsub.Message) string
+
+// SubscriptionKey is the KeyFunc which applies the rate limit per subscription.
+func SubscriptionKey(info *pm.SubscriptionInfo, _ *pubsub.Message) string {
+	return info.SubscriptionID
+}
+
+// AttributeKey returns the KeyFunc which applies the rate limit per value of the given attribute such as a tenant ID.
+// The keys are shared between subscriptions using the same Limiter.
+// When the attribute is not set, the rate limit is applied per subscription.
+func AttributeKey(attribute string) KeyFunc {
+	return func(info *pm.SubscriptionInfo, m *pubsub.Message) string {
+		if v, ok := m.Attributes[attribute]; ok {
+			return v
+		}
+		return info.SubscriptionID
+	}
+}
+
+type options struct {
+	keyFunc   KeyFunc
+	keyLimits map[string]Limit
+	maxWait   time.Duration
+}
+
+type Option func(*options)
+
+// WithKeyFunc customizes the function for deciding the key which the rate limit is applied to.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(o *options) {
+		o.keyFunc = f
+	}
+}
+
+// WithKeyLimits overrides the rate limit for the given keys.
+func WithKeyLimits(limits map[string]Limit) Option {
+	return func(o *options) {
+		o.keyLimits = limits
+	}
+}
+
+// WithMaxWait customizes how long the message waits for a token.
+// When no token is available within the duration, the message is nacked.
+func WithMaxWait(d time.Duration) Option {
+	return func(o *options) {
+		o.maxWait = d
+	}
+}
+
+// WithWaitUntilAvailable makes the message wait for a token as long as the message context allows
+// instead of being nacked.
+func WithWaitUntilAvailable() Option {
+	return WithMaxWait(time.Duration(math.MaxInt64))
+}
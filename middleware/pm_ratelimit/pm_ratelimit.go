@@ -0,0 +1,109 @@
+package pm_ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// ErrRateLimited is returned when no token is available within the max wait.
+var ErrRateLimited = errors.New("pm_ratelimit: rate limit exceeded")
+
+// Limit defines the rate of the token bucket.
+type Limit struct {
+	// PerSecond is the number of messages allowed per second.
+	PerSecond float64
+	// Burst is the maximum number of messages allowed at once. Defaults to 1.
+	Burst int
+}
+
+func (l Limit) validate() {
+	// the negated comparison also rejects NaN.
+	if !(l.PerSecond > 0) {
+		panic(fmt.Sprintf("pm_ratelimit: PerSecond must be positive, got: %v", l.PerSecond))
+	}
+}
+
+func (l Limit) burst() int {
+	if l.Burst <= 0 {
+		return 1
+	}
+	return l.Burst
+}
+
+// Limiter takes tokens from the bucket of the key.
+type Limiter interface {
+	// Take takes a token and returns how long to wait until the token is available.
+	// When the token is not available within maxWait, it returns false without taking the token.
+	Take(ctx context.Context, key string, limit Limit, maxWait time.Duration) (wait time.Duration, ok bool, err error)
+}
+
+// SubscriptionInterceptor holds message handling to the given rate limit.
+// The message waits within its context when a token is available within the max wait,
+// otherwise it's nacked and ErrRateLimited is returned.
+// By default, the rate limit is applied per subscription, and the message waits up to 1 second.
+// It panics when PerSecond of the limit or the key limits isn't positive.
+//
+// // subscriber
+// pubsubSubscriber := pm.NewSubscriber(
+//
+//		pubsubClient,
+//		pm.WithSubscriptionInterceptor(
+//			pm_ratelimit.SubscriptionInterceptor(
+//				pm_ratelimit.NewMemoryLimiter(),
+//				pm_ratelimit.Limit{PerSecond: 10},
+//				pm_ratelimit.WithKeyFunc(pm_ratelimit.AttributeKey("tenant_id")),
+//			),
+//		),
+//	)
+func SubscriptionInterceptor(limiter Limiter, limit Limit, opt ...Option) pm.SubscriptionInterceptor {
+	opts := options{
+		keyFunc: SubscriptionKey,
+		maxWait: 1 * time.Second,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	limit.validate()
+	for _, l := range opts.keyLimits {
+		l.validate()
+	}
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		return func(ctx context.Context, m *pubsub.Message) error {
+			key := opts.keyFunc(info, m)
+			keyLimit := limit
+			if l, ok := opts.keyLimits[key]; ok {
+				keyLimit = l
+			}
+			maxWait := opts.maxWait
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < maxWait {
+				maxWait = time.Until(deadline)
+			}
+
+			wait, ok, err := limiter.Take(ctx, key, keyLimit, maxWait)
+			if err != nil {
+				m.Nack()
+				return err
+			}
+			if !ok {
+				m.Nack()
+				return ErrRateLimited
+			}
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					m.Nack()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+			return next(ctx, m)
+		}
+	}
+}
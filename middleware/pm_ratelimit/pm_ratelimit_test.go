@@ -0,0 +1,145 @@
+package pm_ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+type testLimiter struct {
+	passedKey   string
+	passedLimit Limit
+	wait        time.Duration
+	ok          bool
+}
+
+func (l *testLimiter) Take(_ context.Context, key string, limit Limit, _ time.Duration) (time.Duration, bool, error) {
+	l.passedKey = key
+	l.passedLimit = limit
+	return l.wait, l.ok, nil
+}
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	testSubInfo := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+
+	t.Run("applies the rate limit per subscription by default", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := &testLimiter{ok: true}
+		var called bool
+		interceptor := SubscriptionInterceptor(limiter, Limit{PerSecond: 1})
+		err := interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			called = true
+			return nil
+		})(context.Background(), &pubsub.Message{})
+		if err != nil {
+			t.Errorf("SubscriptionInterceptor is expected to return nil, but got err: %v", err)
+		}
+		if !called {
+			t.Error("The handler is expected to be called")
+		}
+		if limiter.passedKey != "test-sub" {
+			t.Errorf("Take() key got: %v, want: %v", limiter.passedKey, "test-sub")
+		}
+	})
+
+	t.Run("applies the key limit with attribute key", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := &testLimiter{ok: true}
+		interceptor := SubscriptionInterceptor(
+			limiter,
+			Limit{PerSecond: 1},
+			WithKeyFunc(AttributeKey("tenant_id")),
+			WithKeyLimits(map[string]Limit{"tenant-a": {PerSecond: 5}}),
+		)
+		_ = interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			return nil
+		})(context.Background(), &pubsub.Message{Attributes: map[string]string{"tenant_id": "tenant-a"}})
+		if limiter.passedKey != "tenant-a" {
+			t.Errorf("Take() key got: %v, want: %v", limiter.passedKey, "tenant-a")
+		}
+		if limiter.passedLimit.PerSecond != 5 {
+			t.Errorf("Take() limit got: %v, want: %v", limiter.passedLimit.PerSecond, 5)
+		}
+	})
+
+	t.Run("returns ErrRateLimited without calling the handler when no token is available", func(t *testing.T) {
+		t.Parallel()
+
+		var called bool
+		interceptor := SubscriptionInterceptor(&testLimiter{ok: false}, Limit{PerSecond: 1})
+		err := interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			called = true
+			return nil
+		})(context.Background(), &pubsub.Message{})
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("SubscriptionInterceptor is expected to return ErrRateLimited, but got err: %v", err)
+		}
+		if called {
+			t.Error("The handler must not be called")
+		}
+	})
+
+	t.Run("returns context error when the context is done while waiting", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		interceptor := SubscriptionInterceptor(&testLimiter{ok: true, wait: 1 * time.Second}, Limit{PerSecond: 1})
+		err := interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			return nil
+		})(ctx, &pubsub.Message{})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("SubscriptionInterceptor is expected to return context.DeadlineExceeded, but got err: %v", err)
+		}
+	})
+
+	t.Run("holds handling to the rate limit with memory limiter", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := SubscriptionInterceptor(NewMemoryLimiter(), Limit{PerSecond: 20})
+		h := interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			return nil
+		})
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			if err := h(context.Background(), &pubsub.Message{}); err != nil {
+				t.Fatalf("SubscriptionInterceptor is expected to return nil, but got err: %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("5 messages with 20/s limit are expected to take at least 200ms, but took %v", elapsed)
+		}
+	})
+}
+
+func TestSubscriptionInterceptor_invalidLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		limit Limit
+		opt   []Option
+	}{
+		{name: "zero PerSecond", limit: Limit{PerSecond: 0}},
+		{name: "negative PerSecond", limit: Limit{PerSecond: -1}},
+		{name: "zero PerSecond of key limit", limit: Limit{PerSecond: 1}, opt: []Option{WithKeyLimits(map[string]Limit{"key": {}})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" panics", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("SubscriptionInterceptor is expected to panic")
+				}
+			}()
+			SubscriptionInterceptor(NewMemoryLimiter(), tt.limit, tt.opt...)
+		})
+	}
+}
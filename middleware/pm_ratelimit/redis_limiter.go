@@ -0,0 +1,67 @@
+package pm_ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// gcraScript takes a token with GCRA (generic cell rate algorithm).
+// The key holds the theoretical arrival time in microseconds, and Redis server time is used
+// so that the clocks of the replicas don't matter.
+// It returns the wait in microseconds, or -1 when the token is not available within the max wait.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local max_wait = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local wait = new_tat - interval * burst - now
+if wait < 0 then
+	wait = 0
+end
+if wait > max_wait then
+	return -1
+end
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return wait
+`)
+
+type redisLimiter struct {
+	redisClient *redis.Client
+	keyPrefix   string
+}
+
+// NewRedisLimiter initializes a Limiter which shares the budget through Redis.
+// The rate limit is applied across all the processes using the same key prefix.
+func NewRedisLimiter(redisClient *redis.Client, keyPrefix string) Limiter {
+	return &redisLimiter{
+		redisClient: redisClient,
+		keyPrefix:   keyPrefix,
+	}
+}
+
+func (l *redisLimiter) Take(ctx context.Context, key string, limit Limit, maxWait time.Duration) (time.Duration, bool, error) {
+	interval := int64(float64(time.Second/time.Microsecond) / limit.PerSecond)
+	wait, err := gcraScript.Run(
+		ctx,
+		l.redisClient,
+		[]string{l.keyPrefix + ":" + key},
+		interval,
+		limit.burst(),
+		maxWait.Microseconds(),
+	).Int64()
+	if err != nil {
+		return 0, false, err
+	}
+	if wait < 0 {
+		return 0, false, nil
+	}
+	return time.Duration(wait) * time.Microsecond, true, nil
+}
@@ -0,0 +1,63 @@
+package pm_ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/xid"
+)
+
+func Test_redisLimiter_Take(t *testing.T) {
+	t.Parallel()
+
+	redisClient := redis.NewClient(&redis.Options{Addr: os.Getenv("REDIS_URL")})
+
+	t.Run("takes tokens within the burst without waiting", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewRedisLimiter(redisClient, xid.New().String())
+		for i := 0; i < 3; i++ {
+			wait, ok, err := limiter.Take(context.Background(), "test", Limit{PerSecond: 1, Burst: 3}, 0)
+			if err != nil {
+				t.Fatalf("redisLimiter.Take is expected to return nil, but got err: %v", err)
+			}
+			if !ok || wait != 0 {
+				t.Errorf("redisLimiter.Take is expected to take a token without waiting, got wait: %v, ok: %v", wait, ok)
+			}
+		}
+	})
+
+	t.Run("doesn't take a token when it's not available within max wait", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewRedisLimiter(redisClient, xid.New().String())
+		limit := Limit{PerSecond: 1}
+		if _, _, err := limiter.Take(context.Background(), "test", limit, 0); err != nil {
+			t.Fatalf("redisLimiter.Take is expected to return nil, but got err: %v", err)
+		}
+
+		if _, ok, _ := limiter.Take(context.Background(), "test", limit, 100*time.Millisecond); ok {
+			t.Error("redisLimiter.Take must not take a token which is not available within max wait")
+		}
+		wait, ok, _ := limiter.Take(context.Background(), "test", limit, 2*time.Second)
+		if !ok || wait <= 0 {
+			t.Errorf("redisLimiter.Take is expected to take a token with waiting, got wait: %v, ok: %v", wait, ok)
+		}
+	})
+
+	t.Run("budget is shared between limiters with the same key prefix", func(t *testing.T) {
+		t.Parallel()
+
+		keyPrefix := xid.New().String()
+		limit := Limit{PerSecond: 1}
+		if _, ok, _ := NewRedisLimiter(redisClient, keyPrefix).Take(context.Background(), "test", limit, 0); !ok {
+			t.Fatal("redisLimiter.Take is expected to take a token")
+		}
+		if _, ok, _ := NewRedisLimiter(redisClient, keyPrefix).Take(context.Background(), "test", limit, 0); ok {
+			t.Error("redisLimiter.Take must not take a token which is already taken by another limiter")
+		}
+	})
+}
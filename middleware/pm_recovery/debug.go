@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"strconv"
 	"strings"
 )
 
@@ -163,3 +164,63 @@ func (s prettyStack) decorateSourceLine(line string, useColor bool, num int) (st
 
 	return buf.String(), nil
 }
+
+// Frame is a function call in the stack trace of a panic.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// frames parses the output of debug.Stack into frames, starting from the function which panicked.
+func (s prettyStack) frames(debugStack []byte) []Frame {
+	stack := strings.Split(strings.TrimSpace(string(debugStack)), "\n")
+
+	// skip the goroutine header, or the frames up to the panic call as parse does
+	start := 1
+	for i := len(stack) - 1; i > 0; i-- {
+		if strings.HasPrefix(stack[i], "panic(") {
+			start = i + 2
+			break
+		}
+	}
+
+	var frames []Frame
+	for i := start; i+1 < len(stack); i += 2 {
+		fn := stack[i]
+		if strings.HasPrefix(fn, "created by ") {
+			fn = strings.TrimPrefix(fn, "created by ")
+			if idx := strings.Index(fn, " in goroutine "); idx > 0 {
+				fn = fn[:idx]
+			}
+		} else if idx := strings.LastIndex(fn, "("); idx > 0 && strings.HasSuffix(fn, ")") {
+			fn = fn[:idx]
+		}
+
+		source := strings.TrimSpace(stack[i+1])
+		if idx := strings.Index(source, " "); idx > 0 {
+			source = source[:idx]
+		}
+		frame := Frame{Function: fn, File: source}
+		if idx := strings.LastIndex(source, ":"); idx > 0 {
+			if line, err := strconv.Atoi(source[idx+1:]); err == nil {
+				frame.File = source[:idx]
+				frame.Line = line
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// goroutineID parses the ID of the goroutine from the header of the output of debug.Stack.
+func (s prettyStack) goroutineID(debugStack []byte) int {
+	header, _, _ := strings.Cut(string(debugStack), "\n")
+	header = strings.TrimPrefix(header, "goroutine ")
+	idx := strings.Index(header, " ")
+	if idx < 0 {
+		return 0
+	}
+	id, _ := strconv.Atoi(header[:idx])
+	return id
+}
@@ -7,6 +7,8 @@ import (
 
 type options struct {
 	recoveryHandlerFunc RecoveryHandlerFunc
+	returnPanicError    bool
+	reporters           []Reporter
 }
 
 type Option func(*options)
@@ -30,3 +32,19 @@ func WithDebugRecoveryHandler() Option {
 		}
 	}
 }
+
+// WithReporter adds a Reporter which receives the structured report of each recovered panic.
+// The default recovery handler is not called when a reporter is set, unless WithRecoveryHandler is also set.
+func WithReporter(r Reporter) Option {
+	return func(o *options) {
+		o.reporters = append(o.reporters, r)
+	}
+}
+
+// WithPanicError makes the interceptor return the recovered panic as *PanicError instead of nil,
+// so that the outer interceptors such as pm_autoack regard the panic as a failure.
+func WithPanicError() Option {
+	return func(o *options) {
+		o.returnPanicError = true
+	}
+}
@@ -0,0 +1,33 @@
+package pm_recovery
+
+import (
+	"fmt"
+)
+
+// PanicError is the error returned by SubscriptionInterceptor with WithPanicError when the handler panics,
+// and by the PublishResult of PublishInterceptor when publishing panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace from the function which panicked.
+	Stack []Frame
+}
+
+func newPanicError(p interface{}, debugStack []byte) *PanicError {
+	return &PanicError{
+		Value: p,
+		Stack: prettyStack{}.frames(debugStack),
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it's an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package pm_recovery
 
 import (
 	"context"
+	"runtime/debug"
 
 	"cloud.google.com/go/pubsub/v2"
 	"github.com/zero-color/pm"
@@ -13,20 +14,65 @@ type RecoveryHandlerFunc func(ctx context.Context, p interface{})
 
 // SubscriptionInterceptor recover panic.
 func SubscriptionInterceptor(opt ...Option) pm.SubscriptionInterceptor {
-	opts := options{
-		recoveryHandlerFunc: defaultRecoveryHandler,
-	}
+	opts := options{}
 	for _, o := range opt {
 		o(&opts)
 	}
-	return func(_ *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+	if opts.recoveryHandlerFunc == nil && len(opts.reporters) == 0 {
+		opts.recoveryHandlerFunc = defaultRecoveryHandler
+	}
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
 		return func(ctx context.Context, m *pubsub.Message) (err error) {
 			defer func() {
 				if r := recover(); r != nil {
-					opts.recoveryHandlerFunc(ctx, r)
+					debugStack := debug.Stack()
+					if opts.recoveryHandlerFunc != nil {
+						opts.recoveryHandlerFunc(ctx, r)
+					}
+					if len(opts.reporters) > 0 {
+						report := newSubscriptionReport(info, m, r, debugStack)
+						for _, reporter := range opts.reporters {
+							reporter.Report(ctx, report)
+						}
+					}
+					if opts.returnPanicError {
+						err = newPanicError(r, debugStack)
+					}
 				}
 			}()
 			return next(ctx, m)
 		}
 	}
 }
+
+// PublishInterceptor recover panic in publishing.
+// Instead of crashing the caller, it returns the PublishResult which resolves to *PanicError.
+func PublishInterceptor(opt ...Option) pm.PublishInterceptor {
+	opts := options{}
+	for _, o := range opt {
+		o(&opts)
+	}
+	if opts.recoveryHandlerFunc == nil && len(opts.reporters) == 0 {
+		opts.recoveryHandlerFunc = defaultRecoveryHandler
+	}
+	return func(next pm.MessagePublisher) pm.MessagePublisher {
+		return func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) (result pm.PublishResult) {
+			defer func() {
+				if r := recover(); r != nil {
+					debugStack := debug.Stack()
+					if opts.recoveryHandlerFunc != nil {
+						opts.recoveryHandlerFunc(ctx, r)
+					}
+					if len(opts.reporters) > 0 {
+						report := newPublishReport(topic, m, r, debugStack)
+						for _, reporter := range opts.reporters {
+							reporter.Report(ctx, report)
+						}
+					}
+					result = pm.NewPublishResult("", newPanicError(r, debugStack))
+				}
+			}()
+			return next(ctx, topic, m)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package pm_recovery
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"cloud.google.com/go/pubsub/v2"
@@ -42,4 +44,98 @@ func TestSubscriptionInterceptor(t *testing.T) {
 		interceptor := SubscriptionInterceptor(opts...)
 		_ = interceptor(&pm.SubscriptionInfo{}, next)(context.Background(), &pubsub.Message{})
 	})
+
+	t.Run("returns nil without WithPanicError", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := SubscriptionInterceptor(WithRecoveryHandler(func(ctx context.Context, p interface{}) {}))
+		if err := interceptor(&pm.SubscriptionInfo{}, next)(context.Background(), &pubsub.Message{}); err != nil {
+			t.Errorf("SubscriptionInterceptor() is expected to return nil, but got err: %v", err)
+		}
+	})
+
+	t.Run("returns PanicError with WithPanicError", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := SubscriptionInterceptor(
+			WithRecoveryHandler(func(ctx context.Context, p interface{}) {}),
+			WithPanicError(),
+		)
+		err := interceptor(&pm.SubscriptionInfo{}, next)(context.Background(), &pubsub.Message{})
+
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("SubscriptionInterceptor() is expected to return *PanicError, but got err: %v", err)
+		}
+		if panicErr.Value != "panic" {
+			t.Errorf("PanicError.Value got: %v, want: %v", panicErr.Value, "panic")
+		}
+		if len(panicErr.Stack) == 0 {
+			t.Fatal("PanicError.Stack is expected to be parsed, but it's empty")
+		}
+		if top := panicErr.Stack[0]; !strings.Contains(top.Function, "TestSubscriptionInterceptor") || !strings.HasSuffix(top.File, "pm_recovery_test.go") || top.Line == 0 {
+			t.Errorf("The first frame is expected to be the panicked function, got: %+v", top)
+		}
+	})
+
+	t.Run("PanicError unwraps the panic value if it's an error", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("error")
+		interceptor := SubscriptionInterceptor(
+			WithRecoveryHandler(func(ctx context.Context, p interface{}) {}),
+			WithPanicError(),
+		)
+		err := interceptor(&pm.SubscriptionInfo{}, func(ctx context.Context, m *pubsub.Message) error {
+			panic(wantErr)
+		})(context.Background(), &pubsub.Message{})
+		if !errors.Is(err, wantErr) {
+			t.Errorf("SubscriptionInterceptor() is expected to return an error wrapping %v, but got err: %v", wantErr, err)
+		}
+	})
+}
+
+func TestPublishInterceptor(t *testing.T) {
+	t.Parallel()
+
+	topic := (&pubsub.Client{}).Publisher("projects/test-project/topics/test-topic")
+	next := func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) pm.PublishResult {
+		m.Attributes["key"] = "value"
+		return nil
+	}
+
+	t.Run("returns PublishResult which resolves to PanicError", func(t *testing.T) {
+		t.Parallel()
+
+		var report *Report
+		interceptor := PublishInterceptor(WithReporter(ReporterFunc(func(ctx context.Context, r *Report) {
+			report = r
+		})))
+		result := interceptor(next)(context.Background(), topic, &pubsub.Message{})
+
+		_, err := result.Get(context.Background())
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("PublishResult is expected to resolve to *PanicError, but got err: %v", err)
+		}
+		if len(panicErr.Stack) == 0 || !strings.Contains(panicErr.Stack[0].Function, "TestPublishInterceptor") {
+			t.Errorf("The first frame is expected to be the panicked function, got: %+v", panicErr.Stack)
+		}
+		if report == nil || report.TopicID != "test-topic" {
+			t.Errorf("The panic is expected to be reported with the topic, got: %+v", report)
+		}
+	})
+
+	t.Run("recovers with custom recovery handler", func(t *testing.T) {
+		t.Parallel()
+
+		var called bool
+		interceptor := PublishInterceptor(WithRecoveryHandler(func(ctx context.Context, p interface{}) {
+			called = true
+		}))
+		_ = interceptor(next)(context.Background(), topic, &pubsub.Message{})
+		if !called {
+			t.Error("The custom recovery handler is not called")
+		}
+	})
 }
@@ -0,0 +1,135 @@
+package pm_recovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+	"go.uber.org/zap"
+)
+
+// Report is a structured report of a panic recovered by the interceptor.
+type Report struct {
+	Time time.Time `json:"time"`
+	// Type is the type of the panic value, such as "string" or "*errors.errorString".
+	Type string `json:"type"`
+	// Value is the panic value formatted with fmt.Sprint.
+	Value string `json:"value"`
+	// Goroutine is the ID of the goroutine which panicked.
+	Goroutine int `json:"goroutine"`
+	// Frames is the stack trace from the function which panicked.
+	Frames []Frame `json:"frames"`
+	// SubscriptionID is set when the panic is recovered by SubscriptionInterceptor.
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	// TopicID is set when the panic is recovered by PublishInterceptor.
+	TopicID    string            `json:"topic_id,omitempty"`
+	MessageID  string            `json:"message_id,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+func newReport(m *pubsub.Message, p interface{}, debugStack []byte) *Report {
+	s := prettyStack{}
+	return &Report{
+		Time:       time.Now(),
+		Type:       fmt.Sprintf("%T", p),
+		Value:      fmt.Sprint(p),
+		Goroutine:  s.goroutineID(debugStack),
+		Frames:     s.frames(debugStack),
+		MessageID:  m.ID,
+		Attributes: maps.Clone(m.Attributes),
+	}
+}
+
+func newSubscriptionReport(info *pm.SubscriptionInfo, m *pubsub.Message, p interface{}, debugStack []byte) *Report {
+	report := newReport(m, p, debugStack)
+	report.SubscriptionID = info.SubscriptionID
+	return report
+}
+
+func newPublishReport(topic *pubsub.Publisher, m *pubsub.Message, p interface{}, debugStack []byte) *Report {
+	report := newReport(m, p, debugStack)
+	report.TopicID = topic.ID()
+	return report
+}
+
+// Reporter receives the report of a recovered panic.
+type Reporter interface {
+	Report(ctx context.Context, report *Report)
+}
+
+// ReporterFunc is an adapter to use an ordinary function as Reporter.
+type ReporterFunc func(ctx context.Context, report *Report)
+
+// Report calls f(ctx, report).
+func (f ReporterFunc) Report(ctx context.Context, report *Report) {
+	f(ctx, report)
+}
+
+type jsonReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONReporter returns a Reporter which writes each report to w as a line of JSON.
+func NewJSONReporter(w io.Writer) Reporter {
+	return &jsonReporter{w: w}
+}
+
+func (r *jsonReporter) Report(_ context.Context, report *Report) {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(b, '\n'))
+}
+
+type slogReporter struct {
+	logger *slog.Logger
+}
+
+// NewSlogReporter returns a Reporter which logs each report as an error log of slog.
+func NewSlogReporter(logger *slog.Logger) Reporter {
+	return &slogReporter{logger: logger}
+}
+
+func (r *slogReporter) Report(ctx context.Context, report *Report) {
+	r.logger.ErrorContext(ctx, fmt.Sprintf("recovered from panic: %s", report.Value),
+		slog.String("panic.type", report.Type),
+		slog.Int("panic.goroutine", report.Goroutine),
+		slog.Any("panic.frames", report.Frames),
+		slog.String("pubsub.subscription_id", report.SubscriptionID),
+		slog.String("pubsub.topic_id", report.TopicID),
+		slog.String("pubsub.message_id", report.MessageID),
+		slog.Any("pubsub.attributes", report.Attributes),
+	)
+}
+
+type zapReporter struct {
+	logger *zap.Logger
+}
+
+// NewZapReporter returns a Reporter which logs each report as an error log of zap.
+func NewZapReporter(logger *zap.Logger) Reporter {
+	return &zapReporter{logger: logger}
+}
+
+func (r *zapReporter) Report(_ context.Context, report *Report) {
+	r.logger.Error(fmt.Sprintf("recovered from panic: %s", report.Value),
+		zap.String("panic.type", report.Type),
+		zap.Int("panic.goroutine", report.Goroutine),
+		zap.Any("panic.frames", report.Frames),
+		zap.String("pubsub.subscription_id", report.SubscriptionID),
+		zap.String("pubsub.topic_id", report.TopicID),
+		zap.String("pubsub.message_id", report.MessageID),
+		zap.Any("pubsub.attributes", report.Attributes),
+	)
+}
@@ -0,0 +1,99 @@
+package pm_recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+	"go.uber.org/zap"
+	zapobserver "go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithReporter(t *testing.T) {
+	t.Parallel()
+
+	info := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+	m := &pubsub.Message{ID: "message-id", Attributes: map[string]string{"type": "created"}}
+	next := func(ctx context.Context, m *pubsub.Message) error {
+		panic("panic")
+	}
+
+	t.Run("reports the panic to the reporter", func(t *testing.T) {
+		t.Parallel()
+
+		var report *Report
+		interceptor := SubscriptionInterceptor(WithReporter(ReporterFunc(func(ctx context.Context, r *Report) {
+			report = r
+		})))
+		_ = interceptor(info, next)(context.Background(), m)
+
+		if report == nil {
+			t.Fatal("The reporter is not called")
+		}
+		if report.Type != "string" || report.Value != "panic" {
+			t.Errorf("Report value got: %v %v, want: string panic", report.Type, report.Value)
+		}
+		if report.Goroutine == 0 {
+			t.Error("Report.Goroutine is expected to be parsed")
+		}
+		if len(report.Frames) == 0 || !strings.Contains(report.Frames[0].Function, "TestWithReporter") {
+			t.Errorf("The first frame is expected to be the panicked function, got: %+v", report.Frames)
+		}
+		if report.SubscriptionID != "test-sub" || report.MessageID != "message-id" || report.Attributes["type"] != "created" {
+			t.Errorf("Report is expected to contain the subscription and the message, got: %+v", report)
+		}
+	})
+
+	t.Run("JSON reporter writes the report as JSON", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		interceptor := SubscriptionInterceptor(WithReporter(NewJSONReporter(&buf)))
+		_ = interceptor(info, next)(context.Background(), m)
+
+		var report Report
+		if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatalf("The report is expected to be written as JSON, but got err: %v", err)
+		}
+		if report.Value != "panic" || report.MessageID != "message-id" || len(report.Frames) == 0 {
+			t.Errorf("The written report is unexpected: %+v", report)
+		}
+	})
+
+	t.Run("slog reporter emits an error log", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		interceptor := SubscriptionInterceptor(WithReporter(NewSlogReporter(logger)))
+		_ = interceptor(info, next)(context.Background(), m)
+
+		entry := map[string]any{}
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("An error log is expected to be emitted, but got err: %v", err)
+		}
+		if entry["level"] != "ERROR" || entry["pubsub.message_id"] != "message-id" {
+			t.Errorf("The error log is unexpected: %v", entry)
+		}
+	})
+
+	t.Run("zap reporter emits an error log", func(t *testing.T) {
+		t.Parallel()
+
+		core, obs := zapobserver.New(zap.ErrorLevel)
+		interceptor := SubscriptionInterceptor(WithReporter(NewZapReporter(zap.New(core))))
+		_ = interceptor(info, next)(context.Background(), m)
+
+		if got := obs.Len(); got != 1 {
+			t.Fatalf("Only 1 log is expected to be emitted, got: %v, want: %v", got, 1)
+		}
+		if got := obs.All()[0].ContextMap()["pubsub.subscription_id"]; got != "test-sub" {
+			t.Errorf("pubsub.subscription_id field got: %v, want: %v", got, "test-sub")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package pm_recovery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SentryEvent is an event payload compatible with Sentry's event ingestion API.
+// It can be marshaled to JSON and sent to Sentry, or converted to the event type of a Sentry SDK.
+type SentryEvent struct {
+	EventID   string            `json:"event_id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Level     string            `json:"level"`
+	Platform  string            `json:"platform"`
+	Logger    string            `json:"logger"`
+	Message   string            `json:"message,omitempty"`
+	Exception SentryExceptions  `json:"exception"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Extra     map[string]any    `json:"extra,omitempty"`
+}
+
+// SentryExceptions is the exception interface of a Sentry event.
+type SentryExceptions struct {
+	Values []SentryException `json:"values"`
+}
+
+// SentryException is an exception in a Sentry event.
+type SentryException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value"`
+	Stacktrace SentryStacktrace `json:"stacktrace"`
+}
+
+// SentryStacktrace is the stack trace of a Sentry exception.
+type SentryStacktrace struct {
+	Frames []SentryFrame `json:"frames"`
+}
+
+// SentryFrame is a frame of a Sentry stack trace.
+type SentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+// NewSentryEvent builds a Sentry event from the report.
+// The subscription ID, the topic ID and the message ID are set as tags, and the attributes and the goroutine as extra.
+func NewSentryEvent(report *Report) *SentryEvent {
+	// Sentry expects the frames from the oldest call to the function which panicked.
+	frames := make([]SentryFrame, 0, len(report.Frames))
+	for i := len(report.Frames) - 1; i >= 0; i-- {
+		f := report.Frames[i]
+		module, function := splitFunction(f.Function)
+		frames = append(frames, SentryFrame{
+			Function: function,
+			Module:   module,
+			Filename: filepath.Base(f.File),
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+		})
+	}
+
+	extra := map[string]any{"goroutine": report.Goroutine}
+	if len(report.Attributes) > 0 {
+		extra["attributes"] = report.Attributes
+	}
+
+	return &SentryEvent{
+		EventID:   newSentryEventID(),
+		Timestamp: report.Time,
+		Level:     "fatal",
+		Platform:  "go",
+		Logger:    "pm_recovery",
+		Message:   report.Value,
+		Exception: SentryExceptions{
+			Values: []SentryException{
+				{
+					Type:       report.Type,
+					Value:      report.Value,
+					Stacktrace: SentryStacktrace{Frames: frames},
+				},
+			},
+		},
+		Tags:  sentryTags(report),
+		Extra: extra,
+	}
+}
+
+func sentryTags(report *Report) map[string]string {
+	tags := map[string]string{}
+	if report.SubscriptionID != "" {
+		tags["subscription_id"] = report.SubscriptionID
+	}
+	if report.TopicID != "" {
+		tags["topic_id"] = report.TopicID
+	}
+	if report.MessageID != "" {
+		tags["message_id"] = report.MessageID
+	}
+	return tags
+}
+
+// splitFunction splits a function like "github.com/zero-color/pm.(*Subscriber).Run.func1"
+// into the package path and the function name.
+func splitFunction(f string) (string, string) {
+	slash := strings.LastIndex(f, "/")
+	dot := strings.Index(f[slash+1:], ".")
+	if dot < 0 {
+		return "", f
+	}
+	return f[:slash+1+dot], f[slash+1+dot+1:]
+}
+
+func newSentryEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,49 @@
+package pm_recovery
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewSentryEvent(t *testing.T) {
+	t.Parallel()
+
+	report := &Report{
+		Time:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Type:      "string",
+		Value:     "panic",
+		Goroutine: 18,
+		Frames: []Frame{
+			{Function: "github.com/example/app.handle.func1", File: "/src/app/handler.go", Line: 20},
+			{Function: "main.main", File: "/src/app/main.go", Line: 10},
+		},
+		SubscriptionID: "test-sub",
+		MessageID:      "message-id",
+		Attributes:     map[string]string{"type": "created"},
+	}
+	event := NewSentryEvent(report)
+
+	if len(event.EventID) != 32 {
+		t.Errorf("EventID is expected to be 32 hex characters, got: %v", event.EventID)
+	}
+	if event.Tags["subscription_id"] != "test-sub" || event.Tags["message_id"] != "message-id" {
+		t.Errorf("Tags are expected to contain the subscription and the message, got: %v", event.Tags)
+	}
+	frames := event.Exception.Values[0].Stacktrace.Frames
+	want := []SentryFrame{
+		{Function: "main", Module: "main", Filename: "main.go", AbsPath: "/src/app/main.go", Lineno: 10},
+		{Function: "handle.func1", Module: "github.com/example/app", Filename: "handler.go", AbsPath: "/src/app/handler.go", Lineno: 20},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("Frames got: %+v, want: %+v", frames, want)
+	}
+	for i := range want {
+		if frames[i] != want[i] {
+			t.Errorf("Frames[%d] got: %+v, want: %+v", i, frames[i], want[i])
+		}
+	}
+	if _, err := json.Marshal(event); err != nil {
+		t.Errorf("The event is expected to be marshaled to JSON, but got err: %v", err)
+	}
+}
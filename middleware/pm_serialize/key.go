@@ -0,0 +1,31 @@
+package pm_serialize
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm/internal/jsonkey"
+)
+
+// KeyFunc returns the key of the message. Messages with the same key are handled one at a time.
+// When it returns an empty key, the message is handled without serialization.
+type KeyFunc func(m *pubsub.Message) (string, error)
+
+// AttributeKey returns the KeyFunc which extracts the key from the given attribute.
+func AttributeKey(attribute string) KeyFunc {
+	return func(m *pubsub.Message) (string, error) {
+		return m.Attributes[attribute], nil
+	}
+}
+
+// JSONKey returns the KeyFunc which extracts the key from the field of the JSON payload.
+// Nested fields can be specified with multiple field names such as JSONKey("order", "id").
+func JSONKey(fields ...string) KeyFunc {
+	return func(m *pubsub.Message) (string, error) {
+		key, err := jsonkey.Lookup(m.Data, fields...)
+		if err != nil {
+			return "", fmt.Errorf("decode payload of message '%s': %w", m.ID, err)
+		}
+		return key, nil
+	}
+}
@@ -0,0 +1,15 @@
+package pm_serialize
+
+type options struct {
+	maxQueueSize int
+}
+
+type Option func(*options)
+
+// WithMaxQueueSize customizes the maximum number of messages queued per key.
+// When the queue is full, the message is nacked.
+func WithMaxQueueSize(n int) Option {
+	return func(o *options) {
+		o.maxQueueSize = n
+	}
+}
@@ -0,0 +1,119 @@
+package pm_serialize
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// ErrQueueFull is returned when the queue of the key is full.
+var ErrQueueFull = errors.New("pm_serialize: queue is full")
+
+type entry struct {
+	// waiters are the messages waiting for the turn in arrival order.
+	waiters []chan struct{}
+}
+
+type serializer struct {
+	mu           sync.Mutex
+	maxQueueSize int
+	entries      map[string]*entry
+}
+
+func (s *serializer) acquire(ctx context.Context, key string) error {
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	if !ok {
+		s.entries[key] = &entry{}
+		s.mu.Unlock()
+		return nil
+	}
+	if len(e.waiters) >= s.maxQueueSize {
+		s.mu.Unlock()
+		return ErrQueueFull
+	}
+	turn := make(chan struct{})
+	e.waiters = append(e.waiters, turn)
+	s.mu.Unlock()
+
+	select {
+	case <-turn:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, w := range e.waiters {
+			if w == turn {
+				e.waiters = append(e.waiters[:i], e.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// the turn has been already passed to this message, so pass it on.
+		s.releaseLocked(key, e)
+		return ctx.Err()
+	}
+}
+
+func (s *serializer) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked(key, s.entries[key])
+}
+
+func (s *serializer) releaseLocked(key string, e *entry) {
+	if len(e.waiters) == 0 {
+		delete(s.entries, key)
+		return
+	}
+	turn := e.waiters[0]
+	e.waiters = e.waiters[1:]
+	close(turn)
+}
+
+// SubscriptionInterceptor guarantees at most one handler per key is in flight at a time per subscription,
+// even on subscriptions without message ordering.
+// The other messages with the same key are queued in memory and handled in arrival order.
+// When the queue of the key is full, the message is nacked and ErrQueueFull is returned.
+// By default, up to 100 messages are queued per key.
+//
+// // subscriber
+// pubsubSubscriber := pm.NewSubscriber(
+//
+//		pubsubClient,
+//		pm.WithSubscriptionInterceptor(
+//			pm_serialize.SubscriptionInterceptor(pm_serialize.AttributeKey("entity_id")),
+//		),
+//	)
+func SubscriptionInterceptor(keyFunc KeyFunc, opt ...Option) pm.SubscriptionInterceptor {
+	opts := options{
+		maxQueueSize: 100,
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return func(_ *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		s := &serializer{
+			maxQueueSize: opts.maxQueueSize,
+			entries:      map[string]*entry{},
+		}
+		return func(ctx context.Context, m *pubsub.Message) error {
+			key, err := keyFunc(m)
+			if err != nil {
+				m.Nack()
+				return err
+			}
+			if key == "" {
+				return next(ctx, m)
+			}
+			if err := s.acquire(ctx, key); err != nil {
+				m.Nack()
+				return err
+			}
+			defer s.release(key)
+			return next(ctx, m)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package pm_serialize
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	testSubInfo := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+	entityMessage := func(entityID string) *pubsub.Message {
+		return &pubsub.Message{Attributes: map[string]string{"entity_id": entityID}}
+	}
+
+	t.Run("handles at most one message per key at a time", func(t *testing.T) {
+		t.Parallel()
+
+		var inFlight, maxInFlight, handled int32
+		h := SubscriptionInterceptor(AttributeKey("entity_id"))(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			atomic.AddInt32(&handled, 1)
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := h(context.Background(), entityMessage("a")); err != nil {
+					t.Errorf("SubscriptionInterceptor is expected to return nil, but got err: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := atomic.LoadInt32(&maxInFlight); got != 1 {
+			t.Errorf("Only 1 handler is expected to be in flight per key, got: %v", got)
+		}
+		if got := atomic.LoadInt32(&handled); got != 10 {
+			t.Errorf("All messages are expected to be handled, got: %v", got)
+		}
+	})
+
+	t.Run("handles messages with different keys in parallel", func(t *testing.T) {
+		t.Parallel()
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		h := SubscriptionInterceptor(AttributeKey("entity_id"))(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		for _, key := range []string{"a", "b", ""} {
+			key := key
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = h(context.Background(), entityMessage(key))
+			}()
+			<-started
+		}
+		close(finish)
+		wg.Wait()
+	})
+
+	t.Run("returns ErrQueueFull when the queue of the key is full", func(t *testing.T) {
+		t.Parallel()
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		h := SubscriptionInterceptor(AttributeKey("entity_id"), WithMaxQueueSize(0))(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = h(context.Background(), entityMessage("a"))
+		}()
+		<-started
+
+		if err := h(context.Background(), entityMessage("a")); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("SubscriptionInterceptor is expected to return ErrQueueFull, but got err: %v", err)
+		}
+		close(finish)
+		wg.Wait()
+	})
+
+	t.Run("returns context error when the context is done while queued", func(t *testing.T) {
+		t.Parallel()
+
+		started := make(chan struct{})
+		finish := make(chan struct{})
+		var handled int32
+		h := SubscriptionInterceptor(AttributeKey("entity_id"))(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			atomic.AddInt32(&handled, 1)
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = h(context.Background(), entityMessage("a"))
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := h(ctx, entityMessage("a")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("SubscriptionInterceptor is expected to return context.DeadlineExceeded, but got err: %v", err)
+		}
+		close(finish)
+		wg.Wait()
+
+		go func() { <-started }()
+		if err := h(context.Background(), entityMessage("a")); err != nil {
+			t.Errorf("The key is expected to be released, but got err: %v", err)
+		}
+	})
+}
+
+func TestJSONKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fields  []string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "extracts string field", fields: []string{"id"}, data: `{"id": "abc"}`, want: "abc"},
+		{name: "extracts nested number field", fields: []string{"order", "id"}, data: `{"order": {"id": 123}}`, want: "123"},
+		{name: "extracts integer field beyond the float64 precision", fields: []string{"id"}, data: `{"id": 1234567890123456789}`, want: "1234567890123456789"},
+		{name: "returns empty key when the field doesn't exist", fields: []string{"id"}, data: `{"name": "abc"}`, want: ""},
+		{name: "returns error when the payload is not JSON", fields: []string{"id"}, data: `abc`, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := JSONKey(tt.fields...)(&pubsub.Message{Data: []byte(tt.data)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JSONKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("JSONKey() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package pm_timeout
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// OverrunFunc is a function called when the handler keeps running after the timeout and the grace period.
+// It's called while the handler is still running.
+type OverrunFunc func(ctx context.Context, info *pm.SubscriptionInfo, m *pubsub.Message, elapsed time.Duration)
+
+type options struct {
+	subscriptionTimeouts map[string]time.Duration
+	overrunGracePeriod   time.Duration
+	overrunFunc          OverrunFunc
+}
+
+type Option func(*options)
+
+// WithSubscriptionTimeouts overrides the timeout for the given subscription IDs.
+func WithSubscriptionTimeouts(timeouts map[string]time.Duration) Option {
+	return func(o *options) {
+		o.subscriptionTimeouts = timeouts
+	}
+}
+
+// WithOverrunFunc sets the function to report handlers which ignore the cancellation
+// and keep running for the grace period after the timeout.
+func WithOverrunFunc(gracePeriod time.Duration, f OverrunFunc) Option {
+	return func(o *options) {
+		o.overrunGracePeriod = gracePeriod
+		o.overrunFunc = f
+	}
+}
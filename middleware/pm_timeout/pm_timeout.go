@@ -0,0 +1,69 @@
+package pm_timeout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+// TimeoutError is returned when the handler fails after the timeout.
+type TimeoutError struct {
+	SubscriptionID string
+	MessageID      string
+	Timeout        time.Duration
+	// Err is the error returned by the handler.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("pm_timeout: handling message '%s' of subscription '%s' exceeded timeout %s: %v", e.MessageID, e.SubscriptionID, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// SubscriptionInterceptor bounds how long the handler runs by cancelling the handler context after the timeout.
+// When the handler returns an error after the timeout, it's returned as *TimeoutError.
+// The timeout can be overridden per subscription with WithSubscriptionTimeouts, and zero means no timeout.
+func SubscriptionInterceptor(timeout time.Duration, opt ...Option) pm.SubscriptionInterceptor {
+	opts := options{}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return func(info *pm.SubscriptionInfo, next pm.MessageHandler) pm.MessageHandler {
+		subscriptionTimeout := timeout
+		if t, ok := opts.subscriptionTimeouts[info.SubscriptionID]; ok {
+			subscriptionTimeout = t
+		}
+		if subscriptionTimeout <= 0 {
+			return next
+		}
+		return func(ctx context.Context, m *pubsub.Message) error {
+			start := time.Now()
+			timeoutCtx, cancel := context.WithTimeout(ctx, subscriptionTimeout)
+			defer cancel()
+
+			if opts.overrunFunc != nil {
+				timer := time.AfterFunc(subscriptionTimeout+opts.overrunGracePeriod, func() {
+					opts.overrunFunc(ctx, info, m, time.Since(start))
+				})
+				defer timer.Stop()
+			}
+
+			err := next(timeoutCtx, m)
+			if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+				return &TimeoutError{
+					SubscriptionID: info.SubscriptionID,
+					MessageID:      m.ID,
+					Timeout:        subscriptionTimeout,
+					Err:            err,
+				}
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package pm_timeout
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/zero-color/pm"
+)
+
+func TestSubscriptionInterceptor(t *testing.T) {
+	t.Parallel()
+
+	testSubInfo := &pm.SubscriptionInfo{SubscriptionID: "test-sub"}
+
+	waitForCancel := func(ctx context.Context, m *pubsub.Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("cancels the handler context and returns TimeoutError", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := SubscriptionInterceptor(10 * time.Millisecond)
+		err := interceptor(testSubInfo, waitForCancel)(context.Background(), &pubsub.Message{ID: "message-id"})
+
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("SubscriptionInterceptor is expected to return *TimeoutError, but got err: %v", err)
+		}
+		if timeoutErr.MessageID != "message-id" || timeoutErr.SubscriptionID != "test-sub" {
+			t.Errorf("TimeoutError got: %+v", timeoutErr)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("TimeoutError is expected to wrap the handler error, got: %v", err)
+		}
+	})
+
+	t.Run("returns the handler result when it finishes in time", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("error")
+		interceptor := SubscriptionInterceptor(1 * time.Second)
+		err := interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			return wantErr
+		})(context.Background(), &pubsub.Message{})
+		if err != wantErr {
+			t.Errorf("SubscriptionInterceptor got err: %v, want: %v", err, wantErr)
+		}
+	})
+
+	t.Run("applies the timeout of the subscription", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := SubscriptionInterceptor(1*time.Hour, WithSubscriptionTimeouts(map[string]time.Duration{
+			"test-sub": 10 * time.Millisecond,
+		}))
+		err := interceptor(testSubInfo, waitForCancel)(context.Background(), &pubsub.Message{})
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Timeout != 10*time.Millisecond {
+			t.Errorf("SubscriptionInterceptor is expected to return *TimeoutError with the subscription timeout, but got err: %v", err)
+		}
+	})
+
+	t.Run("reports the handler which ignores the cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		var reported int32
+		interceptor := SubscriptionInterceptor(10*time.Millisecond, WithOverrunFunc(10*time.Millisecond, func(_ context.Context, info *pm.SubscriptionInfo, _ *pubsub.Message, elapsed time.Duration) {
+			if elapsed < 20*time.Millisecond {
+				t.Errorf("elapsed is expected to be longer than the timeout and the grace period, got: %v", elapsed)
+			}
+			atomic.AddInt32(&reported, 1)
+		}))
+		_ = interceptor(testSubInfo, func(ctx context.Context, m *pubsub.Message) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})(context.Background(), &pubsub.Message{})
+		if atomic.LoadInt32(&reported) != 1 {
+			t.Errorf("The overrun is expected to be reported once, got: %v", reported)
+		}
+
+		_ = interceptor(testSubInfo, waitForCancel)(context.Background(), &pubsub.Message{})
+		time.Sleep(50 * time.Millisecond)
+		if atomic.LoadInt32(&reported) != 1 {
+			t.Errorf("The handler which respects the cancellation must not be reported, got: %v", reported)
+		}
+	})
+}
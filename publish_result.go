@@ -0,0 +1,37 @@
+package pm
+
+import (
+	"context"
+)
+
+// PublishResult is the result of publishing a message.
+// *pubsub.PublishResult implements it, and publish interceptors can return their own implementation.
+type PublishResult interface {
+	// Ready returns a channel that is closed when the result is ready.
+	Ready() <-chan struct{}
+	// Get returns the server-generated message ID and the error of publishing, blocking until the result is ready.
+	Get(ctx context.Context) (serverID string, err error)
+}
+
+type publishResult struct {
+	ready    chan struct{}
+	serverID string
+	err      error
+}
+
+// NewPublishResult returns a PublishResult which is ready with the server ID and the error.
+// It lets publish interceptors return the result of publishing without calling the next publisher,
+// such as when the publishing panics.
+func NewPublishResult(serverID string, err error) PublishResult {
+	ready := make(chan struct{})
+	close(ready)
+	return &publishResult{ready: ready, serverID: serverID, err: err}
+}
+
+func (r *publishResult) Ready() <-chan struct{} {
+	return r.ready
+}
+
+func (r *publishResult) Get(_ context.Context) (string, error) {
+	return r.serverID, r.err
+}
@@ -0,0 +1,44 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewPublishResult(t *testing.T) {
+	t.Parallel()
+
+	t.Run("result is ready with the server id", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewPublishResult("server-id", nil)
+		select {
+		case <-r.Ready():
+		default:
+			t.Fatal("The result is expected to be ready")
+		}
+
+		serverID, err := r.Get(context.Background())
+		if err != nil {
+			t.Errorf("Get() is expected to return nil, but got err: %v", err)
+		}
+		if serverID != "server-id" {
+			t.Errorf("Get() got: %v, want: %v", serverID, "server-id")
+		}
+	})
+
+	t.Run("result is ready with the error", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("error")
+		r := NewPublishResult("", wantErr)
+		serverID, err := r.Get(context.Background())
+		if err != wantErr {
+			t.Errorf("Get() got err: %v, want: %v", err, wantErr)
+		}
+		if serverID != "" {
+			t.Errorf("Get() got: %v, want empty server id", serverID)
+		}
+	})
+}
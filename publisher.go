@@ -8,7 +8,7 @@ import (
 
 // MessagePublisher defines the message publisher invoked by PublishInterceptor to complete the normal
 // message publishment.
-type MessagePublisher = func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) *pubsub.PublishResult
+type MessagePublisher = func(ctx context.Context, topic *pubsub.Publisher, m *pubsub.Message) PublishResult
 
 // PublishInterceptor provides a hook to intercept the execution of a publishment.
 type PublishInterceptor = func(next MessagePublisher) MessagePublisher
@@ -32,7 +32,7 @@ func NewPublisher(pubsubClient *pubsub.Client, opt ...PublisherOption) *Publishe
 }
 
 // Publish publishes Pub/Sub message with applying middlewares
-func (p *Publisher) Publish(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) *pubsub.PublishResult {
+func (p *Publisher) Publish(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) PublishResult {
 	last := publish
 	for i := len(p.opts.publishInterceptors) - 1; i >= 0; i-- {
 		last = p.opts.publishInterceptors[i](last)
@@ -40,6 +40,6 @@ func (p *Publisher) Publish(ctx context.Context, publisher *pubsub.Publisher, m
 	return last(ctx, publisher, m)
 }
 
-func publish(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) *pubsub.PublishResult {
+func publish(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) PublishResult {
 	return publisher.Publish(ctx, m)
 }
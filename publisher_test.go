@@ -99,12 +99,12 @@ func TestPublisher_Publish(t *testing.T) {
 		{
 			name: "Publish message with interceptors",
 			publisher: NewPublisher(ts.Client, WithPublishInterceptor(func(next MessagePublisher) MessagePublisher {
-				return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) *pubsub.PublishResult {
+				return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) PublishResult {
 					m.Data = []byte("overwritten by first interceptor")
 					return next(ctx, publisher, m)
 				}
 			}, func(next MessagePublisher) MessagePublisher {
-				return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) *pubsub.PublishResult {
+				return func(ctx context.Context, publisher *pubsub.Publisher, m *pubsub.Message) PublishResult {
 					m.Data = []byte("overwritten by last interceptor")
 					return next(ctx, publisher, m)
 				}
@@ -87,7 +87,7 @@ func (s *Subscriber) Run(ctx context.Context) {
 				last = s.opts.subscriptionInterceptors[i](&subscriptionInfo, last)
 			}
 			err := h.subscription.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
-				_ = last(ctx, m)
+				_ = last(ContextWithMessage(ctx, &subscriptionInfo, m), m)
 			})
 			if err != nil {
 				log.Printf("%+v\n", err)
@@ -245,6 +245,12 @@ func TestSubscriber_Run(t *testing.T) {
 		if m.Attributes["intercepted"] != "true" {
 			t.Error("Interceptor didn't work")
 		}
+		if got, ok := MessageFromContext(ctx); !ok || got != m {
+			t.Error("The message is expected to be attached to the context")
+		}
+		if got, ok := SubscriptionInfoFromContext(ctx); !ok || got.SubscriptionID != sub.ID() {
+			t.Error("The subscription info is expected to be attached to the context")
+		}
 		return nil
 	})
 	if err != nil {